@@ -3,12 +3,14 @@ package gcache
 type Value struct {
 	Exists bool
 	Value  interface{}
+	// Err set by LoadingCache if the key is cached as absent or the load failed, ErrNotFound if absent
+	Err error
 }
 
 type Cache interface {
 	// Add the value to the cache, only when the key does not exist
 	Add(key, value interface{}) (added bool)
-	// Put key value to cache
+	// Put key value to cache, a key that cannot be used as a map key is ignored
 	Put(key, value interface{})
 	// Get return cache value
 	Get(key interface{}) (value interface{}, exists bool)
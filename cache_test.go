@@ -0,0 +1,75 @@
+package gcache_test
+
+import (
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+// fullCache is a Cache with the optional interfaces implemented by every Cache of gcache
+type fullCache interface {
+	gcache.Cache
+	gcache.TryPutter
+	gcache.Peeker
+	gcache.EntryInspector
+	gcache.Ranger
+	gcache.Lister
+	gcache.Computer
+	gcache.Tagger
+	gcache.BulkDeleter
+	gcache.Namespacer
+	gcache.Pinner
+	gcache.PinnedPutter
+	gcache.Acquirer
+	gcache.MissRatioEstimator
+}
+
+// fullLowCache is a LowCache with the optional interfaces implemented by every LowCache of gcache
+type fullLowCache interface {
+	gcache.LowCache
+	gcache.Peeker
+	gcache.EntryInspector
+	gcache.Ranger
+	gcache.Pinner
+}
+
+// plainCache hides the optional interfaces of the Cache it wraps, like a Cache not provided by gcache
+type plainCache struct {
+	gcache.Cache
+}
+
+// full returns the optional interfaces of a LowCache created by gcache
+func full(c gcache.LowCache) fullLowCache {
+	return c.(fullLowCache)
+}
+
+func TestOptionalInterfaces(t *testing.T) {
+	caches := []gcache.Cache{
+		gcache.NewLRU(),
+		gcache.NewFIFO(),
+		gcache.NewLFU(),
+		gcache.NewLRUK(),
+	}
+	for _, algorithm := range gcache.Algorithms() {
+		c, e := gcache.New(gcache.Config{Algorithm: algorithm})
+		assert.Nil(t, e)
+		caches = append(caches, c)
+	}
+	caches = append(caches, caches[0].(gcache.Namespacer).Namespace(`ns`))
+	for _, c := range caches {
+		_, ok := c.(fullCache)
+		assert.True(t, ok, `%T`, c)
+	}
+
+	lows := []gcache.LowCache{
+		gcache.NewLowLRU(),
+		gcache.NewLowFIFO(),
+		gcache.NewLowLFU(),
+		gcache.NewLowLRUK(gcache.NewLowLRU(), gcache.NewLowLRU()),
+	}
+	for _, c := range lows {
+		_, ok := c.(fullLowCache)
+		assert.True(t, ok, `%T`, c)
+	}
+}
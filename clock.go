@@ -0,0 +1,100 @@
+package gcache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock is the source of time of a cache, replace it to control expiry in tests, eg. gcachetest.FakeClock
+type Clock interface {
+	Now() time.Time
+	// NewTicker returns a Ticker that drives the sweeper of expired entries
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks like time.Ticker
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the default Clock, it uses package time
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{
+		Ticker: time.NewTicker(d),
+	}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) Chan() <-chan time.Time {
+	return t.C
+}
+
+// CoarseClock is a Clock that reads the time from an atomic, a background goroutine updates it every precision.
+//
+// Now is much cheaper than time.Now on hot paths, but it lags behind by up to precision,
+// so entries may expire up to precision late. Call Stop when it is no longer used.
+type CoarseClock struct {
+	now    int64
+	ticker *time.Ticker
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewCoarseClock create a CoarseClock updated every precision, eg. time.Millisecond
+func NewCoarseClock(precision time.Duration) *CoarseClock {
+	if precision <= 0 {
+		panic(`coarse clock precision must > 0`)
+	}
+	c := &CoarseClock{
+		now:    time.Now().UnixNano(),
+		ticker: time.NewTicker(precision),
+		closed: make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+func (c *CoarseClock) run() {
+	for {
+		select {
+		case <-c.closed:
+			return
+		case now := <-c.ticker.C:
+			atomic.StoreInt64(&c.now, now.UnixNano())
+		}
+	}
+}
+
+// Now returns the time of the last update
+func (c *CoarseClock) Now() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.now))
+}
+
+// UnixNano returns the time of the last update in unix nanoseconds
+func (c *CoarseClock) UnixNano() int64 {
+	return atomic.LoadInt64(&c.now)
+}
+
+// NewTicker returns a ticker of package time
+func (c *CoarseClock) NewTicker(d time.Duration) Ticker {
+	return SystemClock.NewTicker(d)
+}
+
+// Stop the background goroutine, Now keeps returning the last update
+func (c *CoarseClock) Stop() {
+	c.once.Do(func() {
+		c.ticker.Stop()
+		close(c.closed)
+	})
+}
@@ -0,0 +1,43 @@
+package gcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+func benchmarkClockGet(b *testing.B, clock gcache.Clock) {
+	l := gcache.NewLowLRU(
+		gcache.WithLowLRUCapacity(1024),
+		gcache.WithLowLRUExpiry(time.Hour),
+		gcache.WithLowLRUClock(clock),
+	)
+	for i := 0; i < 1024; i++ {
+		l.Put(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Get(i & 1023)
+	}
+}
+func BenchmarkSystemClockGet(b *testing.B) {
+	benchmarkClockGet(b, gcache.SystemClock)
+}
+func BenchmarkCoarseClockGet(b *testing.B) {
+	clock := gcache.NewCoarseClock(time.Millisecond)
+	defer clock.Stop()
+	benchmarkClockGet(b, clock)
+}
+func BenchmarkSystemClockNow(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		gcache.SystemClock.Now()
+	}
+}
+func BenchmarkCoarseClockNow(b *testing.B) {
+	clock := gcache.NewCoarseClock(time.Millisecond)
+	defer clock.Stop()
+	for i := 0; i < b.N; i++ {
+		clock.UnixNano()
+	}
+}
@@ -0,0 +1,83 @@
+package gcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	duration := time.Minute
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUExpiry(duration), gcache.WithLRUClear(duration), gcache.WithLRUClock(clock)),
+		gcache.NewFIFO(gcache.WithFIFOExpiry(duration), gcache.WithFIFOClear(duration), gcache.WithFIFOClock(clock)),
+		gcache.NewLFU(gcache.WithLFUExpiry(duration), gcache.WithLFUClear(duration), gcache.WithLFUClock(clock)),
+		gcache.NewLRUK(gcache.WithLRUKExpiry(duration), gcache.WithLRUKClear(duration), gcache.WithLRUKClock(clock), gcache.WithLRUK(1)),
+	}
+	for _, l := range caches {
+		l.Put(1, 1)
+		info, _ := l.GetEntry(1)
+		assert.Equal(t, clock.Now(), info.Created)
+		assert.Equal(t, duration, info.TTL)
+	}
+	clock.Advance(duration - 1)
+	for _, l := range caches {
+		assert.True(t, l.Contains(1))
+		l.Put(2, 2)
+	}
+	// the sweeper clears 1 before Advance returns
+	clock.Advance(1)
+	for _, l := range caches {
+		assert.Equal(t, 1, l.Len())
+		assert.True(t, l.Contains(2))
+	}
+	clock.Advance(duration)
+	for _, l := range caches {
+		assert.Equal(t, 0, l.Len())
+	}
+
+	// loading
+	loaded := 0
+	c := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		loaded++
+		return loaded, nil
+	}, gcache.WithLoadingExpiry(duration), gcache.WithLoadingClock(clock))
+	v, _ := c.Get(1)
+	assert.Equal(t, 1, v)
+	clock.Advance(duration - 1)
+	v, _ = c.Get(1)
+	assert.Equal(t, 1, v)
+	clock.Advance(1)
+	v, _ = c.Get(1)
+	assert.Equal(t, 2, v)
+}
+
+func TestCoarseClock(t *testing.T) {
+	precision := time.Millisecond * 10
+	clock := gcache.NewCoarseClock(precision)
+	defer clock.Stop()
+
+	now := clock.Now()
+	assert.True(t, time.Since(now) < time.Second)
+	assert.Equal(t, now.UnixNano(), clock.UnixNano())
+	time.Sleep(precision * 5)
+	assert.True(t, clock.Now().After(now))
+
+	l := gcache.NewLRU(gcache.WithLRUExpiry(precision*5), gcache.WithLRUClock(clock))
+	l.Put(1, 1)
+	assert.True(t, l.Contains(1))
+	time.Sleep(precision * 10)
+	assert.False(t, l.Contains(1))
+
+	// Now stops moving after Stop
+	clock.Stop()
+	clock.Stop()
+	time.Sleep(precision * 2)
+	now = clock.Now()
+	time.Sleep(precision * 2)
+	assert.Equal(t, now, clock.Now())
+}
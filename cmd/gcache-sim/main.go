@@ -0,0 +1,112 @@
+// Command gcache-sim replays access traces against the algorithms of gcache and prints their hit ratios.
+//
+//	gcache-sim -policies lru,lfu,2q -capacities 1000,10000 access.log
+//	gcache-sim -format arc -output csv P1.lis > p1.csv
+//	gcache-sim -format csv -csv-key 2 -csv-time 0 -csv-header -expiry 10m requests.csv
+//
+// A request that misses puts the key, as a cache filled on demand does.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	var (
+		format     = flag.String(`format`, FormatKeys, `trace format: keys, arc, lirs or csv`)
+		names      = flag.String(`policies`, strings.Join(policyNames(), `,`), `comma separated policies to simulate`)
+		capacities = flag.String(`capacities`, `100,1000,10000`, `comma separated capacities to simulate`)
+		output     = flag.String(`output`, `table`, `output format: table or csv`)
+		expiry     = flag.Duration(`expiry`, 0, `expire entries after inactivity, requires timestamps`)
+		csvKey     = flag.Int(`csv-key`, 0, `column of keys in csv traces`)
+		csvTime    = flag.Int(`csv-time`, -1, `column of timestamps in csv traces, -1 if none`)
+		csvUnit    = flag.Duration(`csv-unit`, time.Second, `unit of numeric timestamps in csv traces`)
+		csvHeader  = flag.Bool(`csv-header`, false, `skip the first record of csv traces`)
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] trace...\n\nA trace of - is read from stdin.\n\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	caps, e := parseCapacities(*capacities)
+	if e != nil {
+		fatal(e)
+	} else if *output != `table` && *output != `csv` {
+		fatal(fmt.Errorf(`unknown output format: %s`, *output))
+	}
+	traces := flag.Args()
+	if len(traces) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts := csvOptions{
+		key:    *csvKey,
+		time:   *csvTime,
+		unit:   *csvUnit,
+		header: *csvHeader,
+	}
+	policies := strings.Split(*names, `,`)
+	out := newCSVWriter(os.Stdout)
+	for _, name := range traces {
+		results, requests, unique, e := run(name, *format, opts, policies, caps, *expiry)
+		if e != nil {
+			fatal(fmt.Errorf(`%s: %w`, name, e))
+		}
+		if *output == `csv` {
+			e = out.Write(results)
+		} else {
+			e = writeTable(os.Stdout, name, requests, unique, results)
+		}
+		if e != nil {
+			fatal(e)
+		}
+	}
+}
+func run(name, format string, opts csvOptions, policies []string, capacities []int, expiry time.Duration) (results []result, requests, unique int, e error) {
+	var r io.Reader
+	if name == `-` {
+		r = os.Stdin
+	} else {
+		f, e := os.Open(name)
+		if e != nil {
+			return nil, 0, 0, e
+		}
+		defer f.Close()
+		r = f
+	}
+	trace, e := newTraceReader(r, format, opts)
+	if e != nil {
+		return
+	}
+	results, unique, e = replay(name, trace, policies, capacities, expiry)
+	if e == nil && len(results) != 0 {
+		requests = int(results[0].Requests)
+	}
+	return
+}
+func parseCapacities(s string) ([]int, error) {
+	strs := strings.Split(s, `,`)
+	capacities := make([]int, len(strs))
+	for i, str := range strs {
+		capacity, e := strconv.Atoi(strings.TrimSpace(str))
+		if e != nil {
+			return nil, e
+		} else if capacity < 1 {
+			return nil, fmt.Errorf(`capacity must be > 0: %d`, capacity)
+		}
+		capacities[i] = capacity
+	}
+	return capacities, nil
+}
+func fatal(e error) {
+	fmt.Fprintln(os.Stderr, e)
+	os.Exit(1)
+}
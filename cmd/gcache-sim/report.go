@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// writeTable prints the results of a trace as an aligned table
+func writeTable(w io.Writer, trace string, requests, unique int, results []result) error {
+	fmt.Fprintf(w, "trace %s: %d requests, %d unique keys\n", trace, requests, unique)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "policy\tcapacity\thits\tmisses\thit ratio\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f%%\t\n",
+			r.Policy, r.Capacity, r.Hits, r.Misses(), r.HitRatio()*100,
+		)
+	}
+	if e := tw.Flush(); e != nil {
+		return e
+	}
+	_, e := fmt.Fprintln(w)
+	return e
+}
+
+// csvWriter prints the results of all traces as csv with a single header
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{
+		w: csv.NewWriter(w),
+	}
+}
+func (c *csvWriter) Write(results []result) error {
+	if !c.header {
+		c.header = true
+		c.w.Write([]string{`trace`, `policy`, `capacity`, `requests`, `hits`, `misses`, `hit_ratio`})
+	}
+	for _, r := range results {
+		c.w.Write([]string{
+			r.Trace,
+			r.Policy,
+			strconv.Itoa(r.Capacity),
+			strconv.FormatUint(r.Requests, 10),
+			strconv.FormatUint(r.Hits, 10),
+			strconv.FormatUint(r.Misses(), 10),
+			strconv.FormatFloat(r.HitRatio(), 'f', 6, 64),
+		})
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
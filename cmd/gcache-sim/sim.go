@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+// policy creates the LowCache of an algorithm, capacity is the total amount of data it may hold
+type policy func(capacity int, expiry time.Duration, clock gcache.Clock) gcache.LowCache
+
+var policies = map[string]policy{
+	`lru`: func(capacity int, expiry time.Duration, clock gcache.Clock) gcache.LowCache {
+		return newLowLRU(capacity, expiry, clock)
+	},
+	`fifo`: func(capacity int, expiry time.Duration, clock gcache.Clock) gcache.LowCache {
+		return gcache.NewLowFIFO(
+			gcache.WithLowFIFOCapacity(capacity),
+			gcache.WithLowFIFOExpiry(expiry),
+			gcache.WithLowFIFOClock(clock),
+		)
+	},
+	`lfu`: func(capacity int, expiry time.Duration, clock gcache.Clock) gcache.LowCache {
+		return gcache.NewLowLFU(
+			gcache.WithLowLFUCapacity(capacity),
+			gcache.WithLowLFUExpiry(expiry),
+			gcache.WithLowLFUClock(clock),
+		)
+	},
+	`lru2`: newLRUK(2),
+	`lru3`: newLRUK(3),
+	// 2q keeps the values seen once in a fifo of a quarter of the capacity
+	`2q`: func(capacity int, expiry time.Duration, clock gcache.Clock) gcache.LowCache {
+		in := capacity / 4
+		if in < 1 {
+			in = 1
+		}
+		return gcache.NewLowLRUK(
+			gcache.NewLowFIFO(
+				gcache.WithLowFIFOCapacity(in),
+				gcache.WithLowFIFOExpiry(expiry),
+				gcache.WithLowFIFOClock(clock),
+			),
+			newLowLRU(capacity-in, expiry, clock),
+			gcache.WithLowLRUK(2),
+			gcache.WithLowLRUKHistoryOnlyKey(false),
+		)
+	},
+}
+
+// policyNames returns the names of all policies sorted
+func policyNames() []string {
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+func newLowLRU(capacity int, expiry time.Duration, clock gcache.Clock) gcache.LowCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return gcache.NewLowLRU(
+		gcache.WithLowLRUCapacity(capacity),
+		gcache.WithLowLRUExpiry(expiry),
+		gcache.WithLowLRUClock(clock),
+	)
+}
+
+// newLRUK is the default of gcache.NewLRUK, the history only saves keys and holds 10 times the capacity
+func newLRUK(k int) policy {
+	return func(capacity int, expiry time.Duration, clock gcache.Clock) gcache.LowCache {
+		return gcache.NewLowLRUK(
+			newLowLRU(capacity*10, expiry, clock),
+			newLowLRU(capacity, expiry, clock),
+			gcache.WithLowLRUK(k),
+		)
+	}
+}
+
+// traceClock is the time of the request being replayed
+type traceClock struct {
+	now time.Time
+}
+
+func (c *traceClock) Now() time.Time {
+	return c.now
+}
+func (c *traceClock) NewTicker(d time.Duration) gcache.Ticker {
+	return gcache.SystemClock.NewTicker(d)
+}
+
+// result the hits of a policy and capacity
+type result struct {
+	Trace    string
+	Policy   string
+	Capacity int
+	Requests uint64
+	Hits     uint64
+}
+
+func (r result) Misses() uint64 {
+	return r.Requests - r.Hits
+}
+
+// HitRatio returns hits / requests, 0 if there are no requests
+func (r result) HitRatio() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Hits) / float64(r.Requests)
+}
+
+type simulation struct {
+	result
+	cache gcache.LowCache
+}
+
+// access replays a request as one access, Get if the key is cached otherwise Put.
+// Get then Put on a miss would count two accesses in the history of LRU-K.
+func (sim *simulation) access(key interface{}) (hit bool) {
+	if peeker, ok := sim.cache.(gcache.Peeker); !ok || peeker.Contains(key) {
+		_, hit = sim.cache.Get(key)
+	}
+	if !hit {
+		sim.cache.Put(key, struct{}{})
+	}
+	return
+}
+
+// replay the trace against every policy and capacity in one pass.
+// A miss puts the key, as a cache filled on demand does.
+func replay(name string, trace *traceReader, names []string, capacities []int, expiry time.Duration) (results []result, unique int, e error) {
+	clock := &traceClock{}
+	sims := make([]*simulation, 0, len(names)*len(capacities))
+	for _, policy := range names {
+		create, ok := policies[policy]
+		if !ok {
+			e = fmt.Errorf(`unknown policy %s, want one of %s`, policy, strings.Join(policyNames(), `,`))
+			return
+		}
+		for _, capacity := range capacities {
+			sims = append(sims, &simulation{
+				result: result{
+					Trace:    name,
+					Policy:   policy,
+					Capacity: capacity,
+				},
+				cache: create(capacity, expiry, clock),
+			})
+		}
+	}
+	keys := make(map[interface{}]struct{})
+	for {
+		var req request
+		req, e = trace.Next()
+		if e == io.EOF {
+			e = nil
+			break
+		} else if e != nil {
+			return
+		}
+		keys[req.key] = struct{}{}
+		if expiry > 0 {
+			if req.time.IsZero() {
+				e = fmt.Errorf(`expiry requires a trace with timestamps`)
+				return
+			}
+			clock.now = req.time
+		}
+		for _, sim := range sims {
+			sim.Requests++
+			if sim.access(req.key) {
+				sim.Hits++
+			}
+		}
+	}
+	results = make([]result, len(sims))
+	for i, sim := range sims {
+		results[i] = sim.result
+	}
+	unique = len(keys)
+	return
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, trace, format string, opts csvOptions) []request {
+	r, e := newTraceReader(strings.NewReader(trace), format, opts)
+	assert.Nil(t, e)
+	var reqs []request
+	for {
+		req, e := r.Next()
+		if e != nil {
+			break
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+func TestTrace(t *testing.T) {
+	reqs := readAll(t, "a\n\n# comment\n b \n", FormatKeys, csvOptions{})
+	assert.Equal(t, []request{{key: `a`}, {key: `b`}}, reqs)
+
+	reqs = readAll(t, "1\n*\n2\n", FormatLIRS, csvOptions{})
+	assert.Equal(t, []request{{key: int64(1)}, {key: int64(2)}}, reqs)
+
+	reqs = readAll(t, "10 2 0 1\n\n5 1 0 2\n", FormatARC, csvOptions{})
+	assert.Equal(t, []request{{key: int64(10)}, {key: int64(11)}, {key: int64(5)}}, reqs)
+
+	reqs = readAll(t, "time,key\n1.5,a\n2006-01-02T15:04:05Z,b\n", FormatCSV, csvOptions{
+		key:    1,
+		time:   0,
+		unit:   time.Second,
+		header: true,
+	})
+	assert.Equal(t, 2, len(reqs))
+	assert.Equal(t, `a`, reqs[0].key)
+	assert.Equal(t, int64(1500), reqs[0].time.UnixNano()/int64(time.Millisecond))
+	assert.Equal(t, `b`, reqs[1].key)
+	assert.Equal(t, 2006, reqs[1].time.Year())
+
+	r, e := newTraceReader(strings.NewReader("x 1\n"), FormatARC, csvOptions{})
+	assert.Nil(t, e)
+	_, e = r.Next()
+	assert.NotNil(t, e)
+	_, e = newTraceReader(strings.NewReader(""), `unknown`, csvOptions{})
+	assert.NotNil(t, e)
+
+	// the blocks are generated one by one
+	r, e = newTraceReader(strings.NewReader("0 9223372036854775807 0 1\n"), FormatARC, csvOptions{})
+	assert.Nil(t, e)
+	for i := int64(0); i < 3; i++ {
+		req, e := r.Next()
+		assert.Nil(t, e)
+		assert.Equal(t, i, req.key)
+	}
+	for _, trace := range []string{"1 -1\n", "9223372036854775807 2\n"} {
+		r, e = newTraceReader(strings.NewReader(trace), FormatARC, csvOptions{})
+		assert.Nil(t, e)
+		_, e = r.Next()
+		assert.NotNil(t, e, trace)
+	}
+
+	// columns out of range
+	for _, opts := range []csvOptions{{key: -1, time: -1}, {key: 0, time: -2}} {
+		_, e = newTraceReader(strings.NewReader("a\n"), FormatCSV, opts)
+		assert.NotNil(t, e)
+	}
+	r, e = newTraceReader(strings.NewReader("a\n"), FormatCSV, csvOptions{key: 1, time: -1})
+	assert.Nil(t, e)
+	_, e = r.Next()
+	assert.NotNil(t, e)
+}
+
+func TestReplay(t *testing.T) {
+	r, _ := newTraceReader(strings.NewReader("a\nb\na\nc\na\nb\n"), FormatKeys, csvOptions{})
+	results, unique, e := replay(`t`, r, []string{`lru`, `fifo`}, []int{2, 3}, 0)
+	assert.Nil(t, e)
+	assert.Equal(t, 3, unique)
+	assert.Equal(t, []result{
+		{Trace: `t`, Policy: `lru`, Capacity: 2, Requests: 6, Hits: 2},
+		{Trace: `t`, Policy: `lru`, Capacity: 3, Requests: 6, Hits: 3},
+		{Trace: `t`, Policy: `fifo`, Capacity: 2, Requests: 6, Hits: 1},
+		{Trace: `t`, Policy: `fifo`, Capacity: 3, Requests: 6, Hits: 3},
+	}, results)
+
+	// a request is one access, the keys seen once do not evict the hot key from lru2
+	r, _ = newTraceReader(strings.NewReader("a\na\nb\na\nc\na\nd\na\ne\na\n"), FormatKeys, csvOptions{})
+	results, _, e = replay(`t`, r, []string{`lru`, `lru2`}, []int{1}, 0)
+	assert.Nil(t, e)
+	assert.Equal(t, []result{
+		{Trace: `t`, Policy: `lru`, Capacity: 1, Requests: 10, Hits: 1},
+		{Trace: `t`, Policy: `lru2`, Capacity: 1, Requests: 10, Hits: 4},
+	}, results)
+
+	// expiry follows the timestamps
+	r, _ = newTraceReader(strings.NewReader("0,a\n30,a\n700,a\n"), FormatCSV, csvOptions{time: 0, key: 1, unit: time.Second})
+	results, _, e = replay(`t`, r, []string{`lru`}, []int{10}, time.Minute*10)
+	assert.Nil(t, e)
+	assert.Equal(t, uint64(1), results[0].Hits)
+
+	r, _ = newTraceReader(strings.NewReader("a\n"), FormatKeys, csvOptions{})
+	_, _, e = replay(`t`, r, []string{`lru`}, []int{10}, time.Minute)
+	assert.NotNil(t, e)
+	_, _, e = replay(`t`, r, []string{`arc`}, []int{10}, 0)
+	assert.NotNil(t, e)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trace formats
+const (
+	// FormatKeys one key per line, blank lines and lines starting with # are skipped
+	FormatKeys = `keys`
+	// FormatARC start_block number_of_blocks ignore request_number, each block is a request
+	FormatARC = `arc`
+	// FormatLIRS one block number per line, lines that are not a number are skipped
+	FormatLIRS = `lirs`
+	// FormatCSV a column of keys and an optional column of timestamps
+	FormatCSV = `csv`
+)
+
+// request an access of the trace
+type request struct {
+	key interface{}
+	// time of the access, zero if the trace has no timestamps
+	time time.Time
+}
+
+// csvOptions the columns of csv traces
+type csvOptions struct {
+	// key the column of keys
+	key int
+	// time the column of timestamps, -1 if there are none
+	time int
+	// unit of numeric timestamps, RFC3339 timestamps are also accepted
+	unit time.Duration
+	// header skips the first record
+	header bool
+}
+
+// traceReader returns the requests of a trace one by one
+type traceReader struct {
+	next    func() ([]request, error)
+	pending []request
+	// start and remaining the blocks of an arc record not returned yet, they are generated one by one
+	// so number_of_blocks does not decide the memory used
+	start, remaining int64
+}
+
+func newTraceReader(r io.Reader, format string, opts csvOptions) (*traceReader, error) {
+	t := &traceReader{}
+	switch format {
+	case FormatKeys, FormatLIRS, FormatARC:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		parse := parseKey
+		if format == FormatLIRS {
+			parse = parseLIRS
+		} else if format == FormatARC {
+			parse = func(line string) (reqs []request, e error) {
+				t.start, t.remaining, e = parseARC(line)
+				return
+			}
+		}
+		line := 0
+		t.next = func() ([]request, error) {
+			for scanner.Scan() {
+				line++
+				reqs, e := parse(scanner.Text())
+				if e != nil {
+					return nil, fmt.Errorf(`line %d: %w`, line, e)
+				} else if len(reqs) != 0 || t.remaining != 0 {
+					return reqs, nil
+				}
+			}
+			if e := scanner.Err(); e != nil {
+				return nil, e
+			}
+			return nil, io.EOF
+		}
+	case FormatCSV:
+		if opts.key < 0 {
+			return nil, fmt.Errorf(`invalid csv column of keys: %d`, opts.key)
+		} else if opts.time < -1 {
+			return nil, fmt.Errorf(`invalid csv column of timestamps: %d`, opts.time)
+		}
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+		header := opts.header
+		n := 0
+		t.next = func() ([]request, error) {
+			for {
+				record, e := reader.Read()
+				if e != nil {
+					return nil, e
+				}
+				n++
+				if header {
+					header = false
+					continue
+				}
+				req, e := parseCSV(record, opts)
+				if e != nil {
+					return nil, fmt.Errorf(`record %d: %w`, n, e)
+				}
+				return []request{req}, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf(`unknown trace format: %s`, format)
+	}
+	return t, nil
+}
+
+// Next returns the next request, io.EOF at the end of the trace
+func (t *traceReader) Next() (req request, e error) {
+	for len(t.pending) == 0 && t.remaining == 0 {
+		t.pending, e = t.next()
+		if e != nil {
+			return
+		}
+	}
+	if t.remaining != 0 {
+		req.key = t.start
+		t.start++
+		t.remaining--
+		return
+	}
+	req = t.pending[0]
+	t.pending = t.pending[1:]
+	return
+}
+
+func parseKey(line string) ([]request, error) {
+	line = strings.TrimSpace(line)
+	if line == `` || strings.HasPrefix(line, `#`) {
+		return nil, nil
+	}
+	return []request{{key: line}}, nil
+}
+func parseLIRS(line string) ([]request, error) {
+	block, e := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+	if e != nil {
+		return nil, nil
+	}
+	return []request{{key: block}}, nil
+}
+
+// parseARC returns the first block and the number of blocks of the record, 0 blocks for a blank line
+func parseARC(line string) (start, count int64, e error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	} else if len(fields) < 2 {
+		e = fmt.Errorf(`want start_block number_of_blocks, got %q`, line)
+		return
+	}
+	start, e = strconv.ParseInt(fields[0], 10, 64)
+	if e != nil {
+		return
+	}
+	count, e = strconv.ParseInt(fields[1], 10, 64)
+	if e != nil {
+		return
+	} else if count < 0 {
+		e = fmt.Errorf(`negative number of blocks: %d`, count)
+	} else if count > 0 && start > math.MaxInt64-(count-1) {
+		e = fmt.Errorf(`blocks %d + %d overflow`, start, count)
+	}
+	return
+}
+func parseCSV(record []string, opts csvOptions) (req request, e error) {
+	if opts.key >= len(record) || opts.time >= len(record) {
+		e = fmt.Errorf(`want columns %d and %d, got %d columns`, opts.key, opts.time, len(record))
+		return
+	}
+	req.key = record[opts.key]
+	if opts.time >= 0 {
+		req.time, e = parseTime(strings.TrimSpace(record[opts.time]), opts.unit)
+	}
+	return
+}
+func parseTime(s string, unit time.Duration) (time.Time, error) {
+	if f, e := strconv.ParseFloat(s, 64); e == nil {
+		return time.Unix(0, int64(f*float64(unit))), nil
+	}
+	t, e := time.Parse(time.RFC3339Nano, s)
+	if e != nil {
+		return t, errors.New(`timestamp is neither a number nor RFC3339: ` + s)
+	}
+	return t, nil
+}
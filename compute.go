@@ -0,0 +1,19 @@
+package gcache
+
+// Computer is implemented by Cache that read and write a key atomically, all Cache provided by gcache implement it.
+type Computer interface {
+	// Compute calls f with the current value of the key under the cache lock.
+	// If keep is true newValue is stored, otherwise the key is deleted.
+	//
+	// f must not call any method of the cache.
+	Compute(key interface{}, f func(oldValue interface{}, exists bool) (newValue interface{}, keep bool)) (value interface{}, exists bool)
+	// GetOrSet returns the existing value for the key if present, otherwise it stores and returns the given value.
+	// loaded is true if the value was loaded, false if stored.
+	GetOrSet(key, value interface{}) (actual interface{}, loaded bool)
+	// CompareAndSwap swaps the old and new values for key if the value stored in the cache is equal to old.
+	// The old value must be of a comparable type.
+	CompareAndSwap(key, oldValue, newValue interface{}) (swapped bool)
+	// CompareAndDelete deletes the entry for key if its value is equal to old.
+	// The old value must be of a comparable type.
+	CompareAndDelete(key, oldValue interface{}) (deleted bool)
+}
@@ -0,0 +1,149 @@
+package gcache_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	l := gcache.NewLRU()
+	var wait sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for j := 0; j < 100; j++ {
+				l.Compute("counter", func(old interface{}, exists bool) (interface{}, bool) {
+					if exists {
+						return old.(int) + 1, true
+					}
+					return 1, true
+				})
+			}
+		}()
+	}
+	wait.Wait()
+	v, exists := l.Get("counter")
+	assert.True(t, exists)
+	assert.Equal(t, 1000, v)
+
+	v, exists = l.Compute("counter", func(old interface{}, exists bool) (interface{}, bool) {
+		return nil, false
+	})
+	assert.False(t, exists)
+	assert.Nil(t, v)
+	assert.Equal(t, 0, l.Len())
+
+	// get or set
+	v, loaded := l.GetOrSet(1, "a")
+	assert.False(t, loaded)
+	assert.Equal(t, "a", v)
+	v, loaded = l.GetOrSet(1, "b")
+	assert.True(t, loaded)
+	assert.Equal(t, "a", v)
+
+	// compare
+	assert.False(t, l.CompareAndSwap(1, "b", "c"))
+	assert.True(t, l.CompareAndSwap(1, "a", "c"))
+	assert.False(t, l.CompareAndSwap(2, nil, "c"))
+	v, _ = l.Get(1)
+	assert.Equal(t, "c", v)
+	assert.False(t, l.CompareAndDelete(1, "a"))
+	assert.True(t, l.CompareAndDelete(1, "c"))
+	assert.False(t, l.Contains(1))
+
+	// lru-k only saves the key in history
+	k := gcache.NewLRUK(
+		gcache.WithLRUK(2),
+	)
+	v, exists = k.Compute(1, func(old interface{}, exists bool) (interface{}, bool) {
+		return 1, true
+	})
+	assert.False(t, exists)
+	assert.Nil(t, v)
+	v, exists = k.Compute(1, func(old interface{}, exists bool) (interface{}, bool) {
+		return 2, true
+	})
+	assert.True(t, exists)
+	assert.Equal(t, 2, v)
+}
+
+func TestCallbackPanic(t *testing.T) {
+	var panicKey bool
+	l := gcache.NewLRU(
+		gcache.WithLRUCapacity(1),
+		gcache.WithLRUKeyFunc(func(key interface{}) interface{} {
+			if panicKey && key == "panic" {
+				panic("key")
+			}
+			return key
+		}),
+		gcache.WithLRUOnRemoval(func(key, value interface{}, reason gcache.RemovalReason) {
+			if value == "panic" {
+				panic("listener")
+			}
+		}),
+	)
+	// the lock is released, the cache is still usable
+	usable := func() {
+		l.Put("k", 1)
+		v, exists := l.Get("k")
+		assert.True(t, exists)
+		assert.Equal(t, 1, v)
+	}
+
+	assert.Panics(t, func() {
+		l.Compute("k", func(old interface{}, exists bool) (interface{}, bool) {
+			panic("compute")
+		})
+	})
+	usable()
+
+	// uncomparable
+	l.Put("k", []int{1})
+	assert.Panics(t, func() {
+		l.CompareAndSwap("k", []int{1}, 2)
+	})
+	usable()
+	l.Put("k", []int{1})
+	assert.Panics(t, func() {
+		l.CompareAndDelete("k", []int{1})
+	})
+	usable()
+
+	assert.Panics(t, func() {
+		l.Range(func(key, value interface{}) bool {
+			panic("range")
+		})
+	})
+	usable()
+	assert.Panics(t, func() {
+		l.DeleteFunc(func(key, value interface{}) bool {
+			panic("delete")
+		})
+	})
+	usable()
+
+	panicKey = true
+	assert.Panics(t, func() {
+		l.Put("panic", 1)
+	})
+	panicKey = false
+	usable()
+
+	l.Put("k", "panic")
+	assert.Panics(t, func() {
+		l.Delete("k")
+	})
+	usable()
+
+	l.Put("k", "panic")
+	h, exists := l.Acquire("k")
+	assert.True(t, exists)
+	l.Delete("k")
+	assert.Panics(t, h.Release)
+	usable()
+}
@@ -0,0 +1,207 @@
+package gcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidConfig the Config passed to New or the options passed to a TryNewXXX constructor are invalid
+var ErrInvalidConfig = errors.New(`gcache: invalid config`)
+
+// Duration is a time.Duration decoded from a string such as 1m30s or from a number of nanoseconds
+type Duration time.Duration
+
+// MarshalText returns the duration formatted by time.Duration.String
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText parses the duration by time.ParseDuration
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, e := time.ParseDuration(string(text))
+	if e != nil {
+		return e
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// UnmarshalJSON accepts a string or a number of nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		return d.UnmarshalText([]byte(s))
+	}
+	var v int64
+	if e := json.Unmarshal(data, &v); e != nil {
+		return fmt.Errorf(`duration must be a string or a number: %s`, data)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Config describes a Cache, it can be decoded from JSON or YAML. The zero value of a field is its default.
+type Config struct {
+	// Algorithm lru, fifo, lfu, lruk or a name added by Register, default lru
+	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	// Capacity the maximum amount of data to be cached, default 1000
+	Capacity int `json:"capacity" yaml:"capacity"`
+	// Expiry expire after access, 0 never expires
+	Expiry Duration `json:"expiry" yaml:"expiry"`
+	// Clear the interval of the timer that clears expired cache, default 10m, < 0 does not start the timer
+	Clear Duration `json:"clear" yaml:"clear"`
+	// K lruk only, the number of accesses before a key is cached, default 2
+	K int `json:"k" yaml:"k"`
+	// History lruk only, the algorithm of the history: lru, fifo or lfu, default lru
+	History string `json:"history" yaml:"history"`
+	// HistoryOnlyKey lruk only, if true history only save key not save value, nil is true
+	HistoryOnlyKey *bool `json:"historyOnlyKey" yaml:"historyOnlyKey"`
+}
+
+// validate returns the config with the defaults applied, invalid fields are recorded by errs
+func (c Config) validate(errs *optionErrors) Config {
+	if c.Algorithm == `` {
+		c.Algorithm = `lru`
+	}
+	if c.Capacity == 0 {
+		c.Capacity = 1000
+	} else if c.Capacity < 0 {
+		errs.invalid(fmt.Sprintf(`capacity must > 0, got %d`, c.Capacity))
+	}
+	if c.Expiry < 0 {
+		errs.invalid(fmt.Sprintf(`expiry must >= 0, got %v`, time.Duration(c.Expiry)))
+	}
+	if c.Clear == 0 {
+		c.Clear = Duration(time.Minute * 10)
+	}
+	if c.K == 0 {
+		c.K = 2
+	} else if c.K < 0 {
+		errs.invalid(fmt.Sprintf(`k must > 0, got %d`, c.K))
+	}
+	switch c.History {
+	case ``:
+		c.History = `lru`
+	case `lru`, `fifo`, `lfu`:
+	default:
+		errs.invalid(fmt.Sprintf(`unknown history %q, want lru, fifo or lfu`, c.History))
+	}
+	if c.HistoryOnlyKey == nil {
+		onlyKey := true
+		c.HistoryOnlyKey = &onlyKey
+	}
+	return c
+}
+
+// Factory creates the Cache of a registered algorithm, conf has the defaults applied
+type Factory func(conf Config) (Cache, error)
+
+var factories = struct {
+	sync.RWMutex
+	m map[string]Factory
+}{
+	m: map[string]Factory{
+		`lru`:  newLRUFromConfig,
+		`fifo`: newFIFOFromConfig,
+		`lfu`:  newLFUFromConfig,
+		`lruk`: newLRUKFromConfig,
+	},
+}
+
+// Register makes an algorithm available to New by name.
+// If Register is called twice with the same name or if factory is nil, it panics.
+func Register(name string, factory Factory) {
+	if factory == nil {
+		panic(`gcache: Register factory is nil`)
+	}
+	factories.Lock()
+	defer factories.Unlock()
+	if _, dup := factories.m[name]; dup {
+		panic(`gcache: Register called twice for algorithm ` + name)
+	}
+	factories.m[name] = factory
+}
+
+// Algorithms returns the sorted names of the registered algorithms
+func Algorithms() []string {
+	factories.RLock()
+	names := make([]string, 0, len(factories.m))
+	for name := range factories.m {
+		names = append(names, name)
+	}
+	factories.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// New creates the Cache described by conf.
+// It returns an *OptionError listing every invalid field instead of panicking, it wraps ErrInvalidConfig.
+func New(conf Config) (Cache, error) {
+	var errs optionErrors
+	conf = conf.validate(&errs)
+	factories.RLock()
+	factory := factories.m[conf.Algorithm]
+	factories.RUnlock()
+	if factory == nil {
+		errs.invalid(fmt.Sprintf(`unknown algorithm %q, want one of %s`,
+			conf.Algorithm, strings.Join(Algorithms(), `, `),
+		))
+	}
+	if e := errs.err(); e != nil {
+		return nil, e
+	}
+	return factory(conf)
+}
+
+func newLRUFromConfig(conf Config) (Cache, error) {
+	return NewLRU(
+		WithLRUCapacity(conf.Capacity),
+		WithLRUExpiry(time.Duration(conf.Expiry)),
+		WithLRUClear(time.Duration(conf.Clear)),
+	), nil
+}
+func newFIFOFromConfig(conf Config) (Cache, error) {
+	return NewFIFO(
+		WithFIFOCapacity(conf.Capacity),
+		WithFIFOExpiry(time.Duration(conf.Expiry)),
+		WithFIFOClear(time.Duration(conf.Clear)),
+	), nil
+}
+func newLFUFromConfig(conf Config) (Cache, error) {
+	return NewLFU(
+		WithLFUCapacity(conf.Capacity),
+		WithLFUExpiry(time.Duration(conf.Expiry)),
+		WithLFUClear(time.Duration(conf.Clear)),
+	), nil
+}
+func newLRUKFromConfig(conf Config) (Cache, error) {
+	expiry := time.Duration(conf.Expiry)
+	onlyKey := *conf.HistoryOnlyKey
+	opts := []LRUKOption{
+		WithLRUKCapacity(conf.Capacity),
+		WithLRUKExpiry(expiry),
+		WithLRUKClear(time.Duration(conf.Clear)),
+		WithLRUK(conf.K),
+		WithLRUKHistoryOnlyKey(onlyKey),
+	}
+	// the default history of NewLRUK is lru
+	if conf.K > 1 && conf.History != `lru` {
+		capacity := conf.Capacity
+		if onlyKey {
+			capacity *= 10
+		}
+		var history LowCache
+		if conf.History == `fifo` {
+			history = NewLowFIFO(WithLowFIFOCapacity(capacity), WithLowFIFOExpiry(expiry))
+		} else {
+			history = NewLowLFU(WithLowLFUCapacity(capacity), WithLowLFUExpiry(expiry))
+		}
+		opts = append(opts, WithLRUKHistory(history))
+	}
+	return NewLRUK(opts...), nil
+}
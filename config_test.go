@@ -0,0 +1,89 @@
+package gcache_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	for _, algorithm := range []string{``, `lru`, `fifo`, `lfu`, `lruk`} {
+		c, e := gcache.New(gcache.Config{
+			Algorithm: algorithm,
+			Capacity:  2,
+			K:         1,
+		})
+		assert.Nil(t, e)
+		c.Put(1, 1)
+		c.Put(2, 2)
+		c.Put(3, 3)
+		assert.Equal(t, 2, c.Len())
+	}
+
+	// lru-k with a fifo history saving values
+	var conf gcache.Config
+	e := json.Unmarshal([]byte(`{
+		"algorithm": "lruk",
+		"capacity": 10,
+		"expiry": "1m",
+		"clear": -1,
+		"k": 2,
+		"history": "fifo",
+		"historyOnlyKey": false
+	}`), &conf)
+	assert.Nil(t, e)
+	assert.Equal(t, gcache.Duration(time.Minute), conf.Expiry)
+	assert.Equal(t, gcache.Duration(-1), conf.Clear)
+	c, e := gcache.New(conf)
+	assert.Nil(t, e)
+	c.Put(1, 1)
+	info, exists := c.(gcache.EntryInspector).GetEntry(1)
+	assert.True(t, exists)
+	assert.Equal(t, `history`, info.Segment)
+	assert.True(t, info.TTL > 0 && info.TTL <= time.Minute)
+
+	b, e := json.Marshal(conf)
+	assert.Nil(t, e)
+	assert.Contains(t, string(b), `"expiry":"1m0s"`)
+
+	// errors instead of panics
+	for _, conf := range []gcache.Config{
+		{Algorithm: `arc`},
+		{Capacity: -1},
+		{Expiry: -1},
+		{Algorithm: `lruk`, K: -1},
+		{Algorithm: `lruk`, History: `arc`},
+	} {
+		c, e := gcache.New(conf)
+		assert.Nil(t, c)
+		assert.True(t, errors.Is(e, gcache.ErrInvalidConfig), e)
+	}
+	assert.NotNil(t, json.Unmarshal([]byte(`{"expiry":"1x"}`), &conf))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"expiry":true}`), &conf))
+}
+
+func TestRegister(t *testing.T) {
+	gcache.Register(`test-fifo`, func(conf gcache.Config) (gcache.Cache, error) {
+		return gcache.NewFIFO(gcache.WithFIFOCapacity(conf.Capacity)), nil
+	})
+	t.Cleanup(func() {
+		gcache.Unregister(`test-fifo`)
+	})
+	assert.Contains(t, gcache.Algorithms(), `test-fifo`)
+	c, e := gcache.New(gcache.Config{Algorithm: `test-fifo`})
+	assert.Nil(t, e)
+	assert.NotNil(t, c)
+
+	assert.Panics(t, func() {
+		gcache.Register(`test-fifo`, func(conf gcache.Config) (gcache.Cache, error) {
+			return nil, nil
+		})
+	})
+	assert.Panics(t, func() {
+		gcache.Register(`test-nil`, nil)
+	})
+}
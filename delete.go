@@ -0,0 +1,10 @@
+package gcache
+
+// BulkDeleter is implemented by Cache that delete the keys matching a condition, all Cache provided by gcache implement it.
+type BulkDeleter interface {
+	// DeleteFunc deletes every live entry for which f returns true, returns the number of deleted keys.
+	// f must not call any method of the cache.
+	DeleteFunc(f func(key, value interface{}) bool) (changed int)
+	// DeletePrefix deletes every string key starting with prefix, returns the number of deleted keys
+	DeletePrefix(prefix string) (changed int)
+}
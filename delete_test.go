@@ -0,0 +1,112 @@
+package gcache_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteFunc(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(10)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(10)),
+		gcache.NewLFU(gcache.WithLFUCapacity(10)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(10), gcache.WithLRUK(1)),
+	}
+	for _, l := range caches {
+		for i := 0; i < 10; i++ {
+			l.Put(i, i)
+		}
+		assert.Equal(t, 5, l.DeleteFunc(func(key, value interface{}) bool {
+			return value.(int)%2 == 0
+		}))
+		assert.Equal(t, 5, l.Len())
+		assert.False(t, l.Contains(0))
+		assert.True(t, l.Contains(1))
+		assert.Equal(t, 0, l.DeleteFunc(func(key, value interface{}) bool {
+			return false
+		}))
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	newCaches := func(index bool) []fullCache {
+		return []fullCache{
+			gcache.NewLRU(gcache.WithLRUCapacity(10), gcache.WithLRUPrefixIndex(index)),
+			gcache.NewFIFO(gcache.WithFIFOCapacity(10), gcache.WithFIFOPrefixIndex(index)),
+			gcache.NewLFU(gcache.WithLFUCapacity(10), gcache.WithLFUPrefixIndex(index)),
+			gcache.NewLRUK(gcache.WithLRUKCapacity(10), gcache.WithLRUK(1), gcache.WithLRUKPrefixIndex(index)),
+		}
+	}
+	for _, index := range []bool{false, true} {
+		for _, l := range newCaches(index) {
+			l.Put("user/1", 1)
+			l.Put("user/12", 12)
+			l.Put("user/2", 2)
+			l.Put("users", 0)
+			l.Put(1, 1)
+			assert.Equal(t, 2, l.DeletePrefix("user/1"))
+			assert.Equal(t, 0, l.DeletePrefix("user/1"))
+			assert.True(t, l.Contains("user/2"))
+			assert.Equal(t, 2, l.DeletePrefix("user"))
+			assert.Equal(t, 1, l.Len())
+
+			// evicted keys are removed from the index
+			for i := 0; i < 20; i++ {
+				l.Put(fmt.Sprintf("k/%d", i), i)
+			}
+			assert.Equal(t, 10, l.DeletePrefix("k/"))
+			l.Put("a", 1)
+			l.Clear()
+			assert.Equal(t, 0, l.DeletePrefix(""))
+		}
+	}
+
+	// KeyFunc normalises []byte to string
+	l := gcache.NewLRU(gcache.WithLRUKeyFunc(gcache.BytesKey), gcache.WithLRUPrefixIndex(true))
+	l.Put([]byte("abc"), 1)
+	l.Put("abd", 2)
+	assert.Equal(t, 2, l.DeletePrefix("ab"))
+}
+
+func TestDeletePrefixRandom(t *testing.T) {
+	const capacity = 200
+	l := gcache.NewLRU(gcache.WithLRUCapacity(capacity), gcache.WithLRUPrefixIndex(true))
+	scan := gcache.NewLRU(gcache.WithLRUCapacity(capacity))
+	letters := "ab/"
+	randKey := func(n int) string {
+		var sb strings.Builder
+		for i := rand.Intn(n); i >= 0; i-- {
+			sb.WriteByte(letters[rand.Intn(len(letters))])
+		}
+		return sb.String()
+	}
+	for i := 0; i < 5000; i++ {
+		key := randKey(8)
+		switch rand.Intn(4) {
+		case 0:
+			prefix := randKey(3)
+			assert.Equal(t, scan.DeletePrefix(prefix), l.DeletePrefix(prefix), prefix)
+		case 1:
+			assert.Equal(t, scan.Delete(key), l.Delete(key))
+		default:
+			l.Put(key, i)
+			scan.Put(key, i)
+		}
+	}
+	keys := func(c fullCache) []string {
+		var keys []string
+		for _, key := range c.Keys() {
+			keys = append(keys, key.(string))
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	assert.Equal(t, keys(scan), keys(l))
+	assert.Equal(t, l.Len(), l.DeletePrefix(""))
+}
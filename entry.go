@@ -0,0 +1,60 @@
+package gcache
+
+import "time"
+
+// EntryInfo the value and metadata of a cached entry
+type EntryInfo struct {
+	Key   interface{}
+	Value interface{}
+	// Created when the key was added, an expired key put again is created again
+	Created time.Time
+	// Accessed the last read or write
+	Accessed time.Time
+	// Deadline when the entry expires, zero never expires
+	Deadline time.Time
+	// TTL the remaining time to live, NeverExpire if Deadline is zero
+	TTL time.Duration
+	// Count the frequency of lfu or the history count of lru-k, 0 if the algorithm does not count accesses
+	Count int
+	// Segment where the entry lives: lru fifo lfu, or history for the history of lru-k
+	Segment string
+	// Pinned the entry is exempt from eviction
+	Pinned bool
+}
+
+// EntryInspector is implemented by Cache and LowCache that report the metadata of entries, all caches provided by gcache implement it.
+type EntryInspector interface {
+	// GetEntry return the value and metadata of key without updating recency, frequency, history or deadline
+	GetEntry(key interface{}) (info EntryInfo, exists bool)
+}
+
+func newEntryInfo(v cacheValue, segment string, now int64) (info EntryInfo) {
+	created, accessed := v.Touched()
+	deadline, _ := v.GetDeadline()
+	info = EntryInfo{
+		Key:      v.GetKey(),
+		Value:    v.GetValue(),
+		Created:  time.Unix(0, created),
+		Accessed: time.Unix(0, accessed),
+		TTL:      remaining(now, deadline),
+		Segment:  segment,
+		Pinned:   v.Pinned(),
+	}
+	if deadline != 0 {
+		info.Deadline = time.Unix(0, deadline)
+	}
+	return
+}
+
+// getEntry calls GetEntry if c implements EntryInspector, otherwise only the key and value are returned by Peek
+func getEntry(c LowCache, key interface{}) (info EntryInfo, exists bool) {
+	if inspector, ok := c.(EntryInspector); ok {
+		return inspector.GetEntry(key)
+	}
+	info.Value, exists = peek(c, key)
+	if exists {
+		info.Key = key
+		info.TTL = NeverExpire
+	}
+	return
+}
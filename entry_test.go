@@ -0,0 +1,114 @@
+package gcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEntry(t *testing.T) {
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	caches := []struct {
+		cache   fullCache
+		segment string
+	}{
+		{gcache.NewLRU(gcache.WithLRUCapacity(2), gcache.WithLRUClock(clock)), "lru"},
+		{gcache.NewFIFO(gcache.WithFIFOCapacity(2), gcache.WithFIFOClock(clock)), "fifo"},
+		{gcache.NewLFU(gcache.WithLFUCapacity(2), gcache.WithLFUClock(clock)), "lfu"},
+		{gcache.NewLRUK(gcache.WithLRUKCapacity(2), gcache.WithLRUK(1), gcache.WithLRUKClock(clock)), "lru"},
+	}
+	for _, c := range caches {
+		l := c.cache
+		begin := clock.Now()
+		l.Put(1, 1)
+		clock.Advance(time.Millisecond)
+		l.Put(2, 2)
+		l.Get(2)
+
+		info, exists := l.GetEntry(1)
+		assert.True(t, exists)
+		assert.Equal(t, 1, info.Key)
+		assert.Equal(t, 1, info.Value)
+		assert.Equal(t, c.segment, info.Segment)
+		assert.False(t, info.Created.Before(begin))
+		assert.Equal(t, info.Created, info.Accessed)
+		assert.True(t, info.Deadline.IsZero())
+		assert.Equal(t, gcache.NeverExpire, info.TTL)
+
+		info, _ = l.GetEntry(2)
+		assert.True(t, info.Accessed.After(begin))
+		if c.segment == "lfu" {
+			assert.Equal(t, 2, info.Count)
+		}
+
+		// GetEntry does not change the order
+		l.GetEntry(1)
+		l.Put(3, 3)
+		_, exists = l.GetEntry(1)
+		assert.False(t, exists)
+		_, exists = l.GetEntry(4)
+		assert.False(t, exists)
+	}
+
+	// deadline
+	duration := time.Millisecond * 50
+	l := gcache.NewLRU(gcache.WithLRUExpiry(duration), gcache.WithLRUClock(clock))
+	l.Put(1, 1)
+	info, _ := l.GetEntry(1)
+	assert.False(t, info.Deadline.IsZero())
+	assert.Equal(t, duration, info.TTL)
+	clock.Advance(duration)
+	_, exists := l.GetEntry(1)
+	assert.False(t, exists)
+
+	// lru-k history
+	k := gcache.NewLRUK(
+		gcache.WithLRUK(3),
+		gcache.WithLRUKHistoryOnlyKey(false),
+	)
+	k.Put(1, 1)
+	k.Get(1)
+	info, exists = k.GetEntry(1)
+	assert.True(t, exists)
+	assert.Equal(t, "history", info.Segment)
+	assert.Equal(t, 2, info.Count)
+	assert.Equal(t, 1, info.Value)
+	k.Get(1)
+	info, _ = k.GetEntry(1)
+	assert.Equal(t, "lru", info.Segment)
+
+	// namespace
+	k.Namespace("a").Put(1, 2)
+	info, _ = k.Namespace("a").(fullCache).GetEntry(1)
+	assert.Equal(t, 1, info.Key)
+	assert.Equal(t, 2, info.Value)
+}
+
+// plainLowCache hides the optional interfaces of the LowCache it wraps, like a LowCache not provided by gcache
+type plainLowCache struct {
+	gcache.LowCache
+}
+
+func TestGetEntryFallback(t *testing.T) {
+	var _ gcache.LowCache = plainLowCache{}
+	_, ok := interface{}(plainLowCache{}).(gcache.EntryInspector)
+	assert.False(t, ok)
+
+	l := gcache.NewLRUK(
+		gcache.WithLRUK(1),
+		gcache.WithLRUKLRU(plainLowCache{gcache.NewLowLRU()}),
+	)
+	l.Put(1, 2)
+	// only the key and value are returned by Peek
+	info, exists := l.GetEntry(1)
+	assert.True(t, exists)
+	assert.Equal(t, 1, info.Key)
+	assert.Equal(t, 2, info.Value)
+	assert.Equal(t, gcache.NeverExpire, info.TTL)
+	assert.Equal(t, "", info.Segment)
+	_, exists = l.GetEntry(2)
+	assert.False(t, exists)
+}
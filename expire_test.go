@@ -0,0 +1,271 @@
+package gcache_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpireAfterWrite(t *testing.T) {
+	duration := time.Millisecond * 40
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUExpireAfterWrite(duration), gcache.WithLRUClock(clock)),
+		gcache.NewFIFO(gcache.WithFIFOExpireAfterWrite(duration), gcache.WithFIFOClock(clock)),
+		gcache.NewLFU(gcache.WithLFUExpireAfterWrite(duration), gcache.WithLFUClock(clock)),
+		gcache.NewLRUK(gcache.WithLRUK(1), gcache.WithLRUKExpireAfterWrite(duration), gcache.WithLRUKClock(clock)),
+	}
+	for _, l := range caches {
+		l.Put(1, 1)
+	}
+	// hot entries still expire
+	for i := 0; i < 3; i++ {
+		clock.Advance(duration / 4)
+		for _, l := range caches {
+			_, exists := l.Get(1)
+			assert.True(t, exists)
+		}
+	}
+	clock.Advance(duration / 4)
+	for _, l := range caches {
+		_, exists := l.Get(1)
+		assert.False(t, exists)
+	}
+
+	// write refreshes the deadline
+	for _, l := range caches {
+		l.Put(1, 1)
+	}
+	clock.Advance(duration / 2)
+	for _, l := range caches {
+		l.Put(1, 2)
+	}
+	clock.Advance(duration / 2)
+	for _, l := range caches {
+		v, exists := l.Get(1)
+		assert.True(t, exists)
+		assert.Equal(t, 2, v)
+	}
+}
+
+func TestExpireCombined(t *testing.T) {
+	duration := time.Millisecond * 20
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	l := gcache.NewLRU(
+		gcache.WithLRUExpiry(duration),
+		gcache.WithLRUExpireAfterWrite(duration*3),
+		gcache.WithLRUClock(clock),
+	)
+	l.Put(1, 1)
+	l.Put(2, 2)
+	// access keeps 1 alive until the write deadline
+	for i := 0; i < 5; i++ {
+		clock.Advance(duration / 2)
+		_, exists := l.Get(1)
+		assert.True(t, exists)
+	}
+	_, exists := l.Get(2)
+	assert.False(t, exists)
+	clock.Advance(duration / 2)
+	_, exists = l.Get(1)
+	assert.False(t, exists)
+}
+
+type premiumExpiry struct {
+	create, update, read time.Duration
+}
+
+func (e premiumExpiry) AfterCreate(key, value interface{}) time.Duration {
+	return e.create
+}
+func (e premiumExpiry) AfterUpdate(key, value interface{}, remaining time.Duration) time.Duration {
+	return e.update
+}
+func (e premiumExpiry) AfterRead(key, value interface{}, remaining time.Duration) time.Duration {
+	if key == "premium" {
+		return e.read
+	}
+	return remaining
+}
+
+func TestExpiryPolicy(t *testing.T) {
+	duration := time.Millisecond * 20
+	policy := premiumExpiry{
+		create: duration * 2,
+		update: duration,
+		read:   duration * 4,
+	}
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUExpiryPolicy(policy), gcache.WithLRUClock(clock)),
+		gcache.NewFIFO(gcache.WithFIFOExpiryPolicy(policy), gcache.WithFIFOClock(clock)),
+		gcache.NewLFU(gcache.WithLFUExpiryPolicy(policy), gcache.WithLFUClock(clock)),
+		gcache.NewLRUK(gcache.WithLRUK(1), gcache.WithLRUKExpiryPolicy(policy), gcache.WithLRUKClock(clock)),
+	}
+	for _, l := range caches {
+		l.Put("created", 1)
+		l.Put("updated", 1)
+		l.Put("updated", 2)
+		l.Put("premium", 1)
+		l.Get("premium")
+	}
+	clock.Advance(duration * 3 / 2)
+	for _, l := range caches {
+		assert.True(t, l.Contains("created"))
+		assert.False(t, l.Contains("updated"))
+		assert.True(t, l.Contains("premium"))
+	}
+	clock.Advance(duration)
+	for _, l := range caches {
+		assert.False(t, l.Contains("created"))
+		// fifo refreshes the deadline on read too
+		assert.True(t, l.Contains("premium"))
+	}
+
+	// hard limit
+	l := gcache.NewLRU(
+		gcache.WithLRUExpiryPolicy(policy),
+		gcache.WithLRUExpireAfterWrite(duration),
+		gcache.WithLRUClock(clock),
+	)
+	l.Put("premium", 1)
+	clock.Advance(duration)
+	assert.False(t, l.Contains("premium"))
+}
+
+func TestFIFOExpiryRead(t *testing.T) {
+	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	policy := premiumExpiry{
+		create: duration,
+		update: duration,
+		read:   duration,
+	}
+	caches := []fullLowCache{
+		full(gcache.NewLowFIFO(gcache.WithLowFIFOCapacity(2), gcache.WithLowFIFOExpiry(duration), gcache.WithLowFIFOClock(clock))),
+		full(gcache.NewLowFIFO(gcache.WithLowFIFOCapacity(2), gcache.WithLowFIFOExpiryPolicy(policy), gcache.WithLowFIFOClock(clock))),
+	}
+	for _, l := range caches {
+		l.Put("premium", 1)
+		l.Put(2, 2)
+	}
+	clock.Advance(duration / 2)
+	for _, l := range caches {
+		l.Get("premium")
+	}
+	clock.Advance(duration / 2)
+	for _, l := range caches {
+		assert.True(t, l.Contains("premium"))
+		assert.False(t, l.Contains(2))
+
+		// the read does not change the order of eviction
+		l.Put(2, 2)
+		l.Put(3, 3)
+		assert.False(t, l.Contains("premium"))
+	}
+}
+
+type keyExpiry struct{}
+
+func (keyExpiry) AfterCreate(key, value interface{}) time.Duration {
+	return value.(time.Duration)
+}
+func (keyExpiry) AfterUpdate(key, value interface{}, remaining time.Duration) time.Duration {
+	return value.(time.Duration)
+}
+func (keyExpiry) AfterRead(key, value interface{}, remaining time.Duration) time.Duration {
+	return remaining
+}
+
+func TestClearExpiredOutOfOrder(t *testing.T) {
+	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	caches := []fullLowCache{
+		full(gcache.NewLowLRU(gcache.WithLowLRUExpiryPolicy(keyExpiry{}), gcache.WithLowLRUClock(clock))),
+		full(gcache.NewLowFIFO(gcache.WithLowFIFOExpiryPolicy(keyExpiry{}), gcache.WithLowFIFOClock(clock))),
+		full(gcache.NewLowLFU(gcache.WithLowLFUExpiryPolicy(keyExpiry{}), gcache.WithLowLFUClock(clock))),
+	}
+	for _, l := range caches {
+		// the front of the list expires last
+		l.Put(0, time.Hour*24*30)
+		l.Put(1, time.Hour)
+		l.Put(2, time.Minute)
+		l.Put(3, duration*10)
+		for i := 4; i < 100; i++ {
+			l.Put(i, duration*time.Duration(100-i)/50)
+		}
+	}
+	clock.Advance(duration * 2)
+	for _, l := range caches {
+		l.ClearExpired()
+		assert.Equal(t, 4, l.Len())
+	}
+	clock.Advance(duration * 6)
+	for _, l := range caches {
+		l.ClearExpired()
+		assert.Equal(t, 4, l.Len())
+	}
+	clock.Advance(duration * 4)
+	for _, l := range caches {
+		l.ClearExpired()
+		assert.Equal(t, 3, l.Len())
+		assert.True(t, l.Contains(0))
+		assert.True(t, l.Contains(1))
+		assert.True(t, l.Contains(2))
+	}
+}
+
+func TestClearExpiredRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	expired := make(map[interface{}]bool)
+	l := gcache.NewLowLRU(
+		gcache.WithCapacity(1000),
+		gcache.WithExpiryPolicy(keyExpiry{}),
+		gcache.WithClock(clock),
+		gcache.WithOnRemoval(func(key, value interface{}, reason gcache.RemovalReason) {
+			if reason == gcache.RemovalExpired {
+				expired[key] = true
+			}
+		}),
+	)
+	// the deadlines reach every level of the wheel, steps below a tick of 2^20ns are frequent
+	spans := []time.Duration{time.Microsecond, time.Millisecond, time.Second, time.Minute, time.Hour, time.Hour * 24 * 30}
+	random := func() time.Duration {
+		return time.Duration(rnd.Int63n(int64(spans[rnd.Intn(len(spans))])))
+	}
+	deadlines := make(map[interface{}]time.Time)
+	for i := 0; i < 20000; i++ {
+		now := clock.Now()
+		switch rnd.Intn(3) {
+		case 0:
+			key := rnd.Intn(500)
+			ttl := random() + 1
+			l.Put(key, ttl)
+			if deadline, ok := deadlines[key]; ok && !deadline.After(now) {
+				assert.True(t, expired[key], `%v put after its deadline`, key)
+			}
+			delete(expired, key)
+			deadlines[key] = now.Add(ttl)
+		case 1:
+			clock.Advance(random() / time.Duration(rnd.Intn(1000)+1))
+		default:
+			l.ClearExpired()
+			for key, deadline := range deadlines {
+				if !deadline.After(now) {
+					assert.True(t, expired[key], `%v expired %v ago`, key, now.Sub(deadline))
+					delete(expired, key)
+					delete(deadlines, key)
+				}
+			}
+			assert.Empty(t, expired)
+			if !assert.Equal(t, len(deadlines), l.Len()) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package gcache
+
+import (
+	"math"
+	"time"
+)
+
+// NeverExpire returned by ExpiryPolicy to indicate the entry will not expire due to time
+const NeverExpire = time.Duration(math.MaxInt64)
+
+// ExpiryPolicy computes the time to live of every entry.
+//
+// A returned duration <= 0 expires the entry immediately, NeverExpire keeps it until evicted.
+type ExpiryPolicy interface {
+	// AfterCreate returns the time to live of a new entry
+	AfterCreate(key, value interface{}) time.Duration
+	// AfterUpdate returns the time to live after the value of an entry was replaced,
+	// remaining is the current time to live, return it to keep the deadline unchanged.
+	AfterUpdate(key, value interface{}, remaining time.Duration) time.Duration
+	// AfterRead returns the time to live after an entry was read,
+	// remaining is the current time to live, return it to keep the deadline unchanged.
+	AfterRead(key, value interface{}, remaining time.Duration) time.Duration
+}
+
+// accessExpiry expire after access
+type accessExpiry time.Duration
+
+func (e accessExpiry) AfterCreate(key, value interface{}) time.Duration {
+	return time.Duration(e)
+}
+func (e accessExpiry) AfterUpdate(key, value interface{}, remaining time.Duration) time.Duration {
+	return time.Duration(e)
+}
+func (e accessExpiry) AfterRead(key, value interface{}, remaining time.Duration) time.Duration {
+	return time.Duration(e)
+}
+
+type expiryEvent uint8
+
+const (
+	expiryCreate expiryEvent = iota
+	expiryUpdate
+	expiryRead
+)
+
+// expiration computes the deadline of values
+type expiration struct {
+	// policy computes the deadline, nil if only expire after write
+	policy ExpiryPolicy
+	// expire after write, a hard limit refreshed only by create and update
+	write time.Duration
+	// wheel schedules the deadlines, created by the low-level cache that owns the values
+	wheel *timingWheel
+	clock Clock
+	// nano reads the clock in unix nanoseconds, it skips time.Time if the clock provides UnixNano
+	nano func() int64
+}
+
+// newExpiration policy overrides access, nil clock is SystemClock
+func newExpiration(access, write time.Duration, policy ExpiryPolicy, clock Clock) expiration {
+	if policy == nil && access > 0 {
+		policy = accessExpiry(access)
+	}
+	if clock == nil {
+		clock = SystemClock
+	}
+	nano := func() int64 {
+		return clock.Now().UnixNano()
+	}
+	if c, ok := clock.(interface{ UnixNano() int64 }); ok {
+		nano = c.UnixNano
+	}
+	return expiration{
+		policy: policy,
+		write:  write,
+		clock:  clock,
+		nano:   nano,
+	}
+}
+func (e expiration) enabled() bool {
+	return e.policy != nil || e.write > 0
+}
+
+// Now returns the time of the clock
+func (e expiration) Now() time.Time {
+	return e.clock.Now()
+}
+
+// Nano returns the time of the clock in unix nanoseconds
+func (e expiration) Nano() int64 {
+	return e.nano()
+}
+
+// IsDeleted reports whether the deadline of v has passed, the clock is read only if expiry is enabled
+func (e expiration) IsDeleted(v cacheValue) bool {
+	return e.enabled() && v.IsDeleted(e.Nano())
+}
+
+// IsDeletedAt reports whether the deadline of v has passed at now
+func (e expiration) IsDeletedAt(v cacheValue, now int64) bool {
+	return e.enabled() && v.IsDeleted(now)
+}
+
+// withWheel returns a copy that schedules deadlines on a new timing wheel
+func (e expiration) withWheel() expiration {
+	if e.enabled() {
+		e.wheel = newTimingWheel(e.Nano())
+	}
+	return e
+}
+
+// ClearExpired calls expired for every value whose deadline has passed
+func (e expiration) ClearExpired(expired func(v cacheValue)) {
+	if e.wheel != nil {
+		e.wheel.Advance(e.Nano(), expired)
+	}
+}
+
+// Remove the value from the timing wheel
+func (e expiration) Remove(v cacheValue) {
+	if e.wheel != nil {
+		e.wheel.Remove(v)
+	}
+}
+
+// Clear the timing wheel
+func (e expiration) Clear() {
+	if e.wheel != nil {
+		e.wheel.Clear()
+	}
+}
+
+// Apply updates the deadline of v after event at now in unix nanoseconds
+func (e expiration) Apply(v cacheValue, event expiryEvent, now int64) {
+	if !e.enabled() {
+		return
+	}
+	var (
+		deadline, expire = v.GetDeadline()
+		ttl              = NeverExpire
+	)
+	switch event {
+	case expiryCreate:
+		expire = e.expire(now)
+		if e.policy != nil {
+			ttl = e.policy.AfterCreate(v.GetKey(), v.GetValue())
+		}
+	case expiryUpdate:
+		expire = e.expire(now)
+		if e.policy != nil {
+			ttl = e.policy.AfterUpdate(v.GetKey(), v.GetValue(), remaining(now, deadline))
+		}
+	default:
+		if e.policy == nil {
+			return
+		}
+		ttl = e.policy.AfterRead(v.GetKey(), v.GetValue(), remaining(now, deadline))
+	}
+	if ttl == NeverExpire {
+		deadline = expire
+	} else {
+		deadline = addTTL(now, ttl)
+		if expire != 0 && expire < deadline {
+			deadline = expire
+		}
+	}
+	v.SetDeadline(deadline, expire)
+	if e.wheel != nil {
+		e.wheel.Schedule(v)
+	}
+}
+func (e expiration) expire(now int64) int64 {
+	if e.write > 0 {
+		return addTTL(now, e.write)
+	}
+	return 0
+}
+
+// addTTL returns the deadline now + ttl, it saturates instead of overflow.
+// Zero means never expires, so a deadline at exactly zero is moved one nanosecond earlier.
+func addTTL(now int64, ttl time.Duration) (deadline int64) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	if now > 0 && int64(ttl) > math.MaxInt64-now {
+		deadline = math.MaxInt64
+	} else {
+		deadline = now + int64(ttl)
+	}
+	if deadline == 0 {
+		deadline = -1
+	}
+	return
+}
+
+// remaining returns the time to live of deadline, a zero deadline never expires
+func remaining(now, deadline int64) time.Duration {
+	if deadline == 0 {
+		return NeverExpire
+	}
+	return time.Duration(deadline - now)
+}
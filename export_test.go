@@ -0,0 +1,119 @@
+package gcache
+
+import "fmt"
+
+// CheckInvariants returns an error describing the first broken invariant of the internal state of c.
+// LowCache not provided by this package always pass.
+func CheckInvariants(c LowCache) error {
+	switch l := c.(type) {
+	case *lrufifo:
+		return l.checkInvariants()
+	case *lowLFU:
+		return l.checkInvariants()
+	case *LowLRUK:
+		return l.checkInvariants()
+	}
+	return nil
+}
+func (l *lrufifo) checkInvariants() error {
+	if len(l.keys) != l.hot.Len() {
+		return fmt.Errorf(`len(keys) = %d, hot.Len() = %d`, len(l.keys), l.hot.Len())
+	} else if l.hot.Len() > l.capacity {
+		return fmt.Errorf(`hot.Len() = %d exceeds capacity %d`, l.hot.Len(), l.capacity)
+	}
+	pinned := 0
+	for ele := l.hot.Front(); ele != nil; ele = ele.Next() {
+		v := ele.Value.(cacheValue)
+		if l.keys[v.MapKey()] != ele {
+			return fmt.Errorf(`keys[%v] is not its element of hot`, v.GetKey())
+		}
+		if v.Pinned() {
+			pinned++
+		}
+	}
+	return checkPinned(pinned, l.pinned, l.pinLimit)
+}
+func (l *lowLFU) checkInvariants() error {
+	h := l.hot.heap
+	if len(l.keys) != len(h) {
+		return fmt.Errorf(`len(keys) = %d, hot.Len() = %d`, len(l.keys), len(h))
+	} else if len(h) > l.capacity {
+		return fmt.Errorf(`hot.Len() = %d exceeds capacity %d`, len(h), l.capacity)
+	}
+	pinned := 0
+	for i, v := range h {
+		if v.GetIndex() != i {
+			return fmt.Errorf(`heap[%d] of %v has index %d`, i, v.GetKey(), v.GetIndex())
+		} else if l.keys[v.MapKey()] != v {
+			return fmt.Errorf(`keys[%v] is not its value of heap`, v.GetKey())
+		} else if i > 0 && lfuLess(v, h[(i-1)/2]) {
+			return fmt.Errorf(`heap[%d] of %v is less than its parent`, i, v.GetKey())
+		}
+		if v.Pinned() {
+			pinned++
+		}
+	}
+	return checkPinned(pinned, l.pinned, l.pinLimit)
+}
+func checkPinned(count, pinned, limit int) error {
+	if count != pinned {
+		return fmt.Errorf(`%d values pinned, pinned = %d`, count, pinned)
+	} else if pinned > limit {
+		return fmt.Errorf(`pinned = %d exceeds limit %d`, pinned, limit)
+	}
+	return nil
+}
+func (l *LowLRUK) checkInvariants() error {
+	if e := CheckInvariants(l.lru); e != nil {
+		return fmt.Errorf(`lru: %w`, e)
+	}
+	count := l.lru.Len()
+	if l.history != nil {
+		if e := CheckInvariants(l.history); e != nil {
+			return fmt.Errorf(`history: %w`, e)
+		}
+		if !l.opts.historyOnlyKey {
+			count += l.history.Len()
+		}
+		var e error
+		rangeLow(l.history, func(key, value interface{}) bool {
+			if kv, ok := value.(kValue); !ok {
+				e = fmt.Errorf(`history value of %v is %T`, key, value)
+			} else if kv.Count >= l.opts.k {
+				e = fmt.Errorf(`history count of %v is %d, k = %d`, key, kv.Count, l.opts.k)
+			} else if contains(l.lru, key) {
+				e = fmt.Errorf(`%v is cached by both lru and history`, key)
+			}
+			return e == nil
+		})
+		if e != nil {
+			return e
+		}
+	}
+	if count != l.Len() {
+		return fmt.Errorf(`Len() = %d, want %d`, l.Len(), count)
+	}
+	visited := 0
+	l.Range(func(key, value interface{}) bool {
+		visited++
+		return true
+	})
+	if visited != count {
+		return fmt.Errorf(`Range visited %d entries, Len() = %d`, visited, count)
+	}
+	return nil
+}
+
+// LoadingCalls returns the number of running loads of l
+func LoadingCalls(l *LoadingCache) int {
+	l.m.Lock()
+	defer l.m.Unlock()
+	return len(l.calls)
+}
+
+// Unregister removes an algorithm registered by a test
+func Unregister(name string) {
+	factories.Lock()
+	delete(factories.m, name)
+	factories.Unlock()
+}
@@ -1,33 +1,57 @@
 package gcache
 
-import (
-	"runtime"
-	"time"
-)
+import "runtime"
 
 type FIFO struct {
 	*wrapper
 }
 
-func NewFIFO(opt ...FIFOOption) (fifo *FIFO) {
+// NewFIFO create a fifo, it panics if an option is invalid
+func NewFIFO(opt ...FIFOOption) *FIFO {
+	fifo, e := TryNewFIFO(opt...)
+	if e != nil {
+		panic(e)
+	}
+	return fifo
+}
+
+// TryNewFIFO is NewFIFO but returns an *OptionError listing every invalid option instead of panicking
+func TryNewFIFO(opt ...FIFOOption) (fifo *FIFO, e error) {
 	opts := defaultFIFOOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyFIFO(&opts)
+	}
+	if e = opts.err(); e != nil {
+		return
+	}
+	opts.keyFunc = namespaceKeyFunc(opts.keyFunc)
+	if opts.clock == nil {
+		opts.clock = SystemClock
 	}
-	w := &wrapper{
-		impl: NewLowFIFO(
+	w := newWrapper(
+		NewLowFIFO(
 			WithLowFIFOCapacity(opts.capacity),
 			WithLowFIFOExpiry(opts.expiry),
+			WithLowFIFOExpireAfterWrite(opts.expireAfterWrite),
+			WithLowFIFOExpiryPolicy(opts.expiryPolicy),
+			WithLowFIFOKeyFunc(opts.keyFunc),
+			WithLowFIFOPinLimit(opts.pinLimit),
+			WithLowFIFOClock(opts.clock),
 		),
-		closed: make(chan struct{}),
+		opts.keyFunc,
+		opts.prefixIndex,
+		opts.onRemoval,
+	)
+	if opts.mrcSamples > 0 {
+		w.mrc = newShards(opts.capacity, opts.mrcSamples)
 	}
 	fifo = &FIFO{
 		wrapper: w,
 	}
-	if opts.expiry > 0 {
-		ticker := time.NewTicker(opts.clear)
+	if opts.clear > 0 && newExpiration(opts.expiry, opts.expireAfterWrite, opts.expiryPolicy, opts.clock).enabled() {
+		ticker := opts.clock.NewTicker(opts.clear)
 		w.ticker = ticker
-		go w.clearExpired(ticker.C)
+		go w.clearExpired(ticker.Chan())
 		runtime.SetFinalizer(fifo, (*FIFO).stop)
 	}
 	return
@@ -1,26 +1,29 @@
 package gcache
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var defaultFIFOOptions = fifoOptions{
-	expiry:   0,
-	capacity: 1000,
-	clear:    time.Minute * 10,
+	commonOptions: commonOptions{
+		capacity: 1000,
+		pinLimit: defaultPinLimit,
+		clear:    time.Minute * 10,
+	},
 }
 
 type fifoOptions struct {
-	expiry   time.Duration
-	capacity int
-	clear    time.Duration
+	commonOptions
 }
 type FIFOOption interface {
-	apply(*fifoOptions)
+	applyFIFO(*fifoOptions)
 }
 type funcFIFOOption struct {
 	f func(*fifoOptions)
 }
 
-func (fdo *funcFIFOOption) apply(do *fifoOptions) {
+func (fdo *funcFIFOOption) applyFIFO(do *fifoOptions) {
 	fdo.f(do)
 }
 func newFuncFIFOOption(f func(*fifoOptions)) *funcFIFOOption {
@@ -29,18 +32,35 @@ func newFuncFIFOOption(f func(*fifoOptions)) *funcFIFOOption {
 	}
 }
 
-// WithFIFOExpiry if <=0, it will not expire due to time
+// WithFIFOExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity
 func WithFIFOExpiry(expiry time.Duration) FIFOOption {
 	return newFuncFIFOOption(func(o *fifoOptions) {
 		o.expiry = expiry
 	})
 }
 
+// WithFIFOExpireAfterWrite expire after write, the deadline is refreshed only by write no matter how often it is read.
+// if <=0, it will not expire due to age. If both are set the entry expires at the earlier deadline.
+func WithFIFOExpireAfterWrite(expiry time.Duration) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithFIFOExpiryPolicy computes a variable time to live for every entry, it overrides WithFIFOExpiry.
+// WithFIFOExpireAfterWrite is still applied as a hard limit.
+func WithFIFOExpiryPolicy(policy ExpiryPolicy) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
 // WithFIFOCapacity set the maximum amount of data to be cached
 func WithFIFOCapacity(capacity int) FIFOOption {
 	return newFuncFIFOOption(func(o *fifoOptions) {
 		if capacity < 1 {
-			panic(`fifo capacity must > 0`)
+			o.invalid(fmt.Sprintf(`fifo capacity must > 0, got %d`, capacity))
+			return
 		}
 		o.capacity = capacity
 	})
@@ -52,3 +72,52 @@ func WithFIFOClear(duration time.Duration) FIFOOption {
 		po.clear = duration
 	})
 }
+
+// WithFIFOKeyFunc normalises keys before they reach the internal map, eg. BytesKey StringsKey
+func WithFIFOKeyFunc(f KeyFunc) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithFIFOPrefixIndex index string keys in a radix tree, so DeletePrefix does not walk the whole cache
+func WithFIFOPrefixIndex(enable bool) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		o.prefixIndex = enable
+	})
+}
+
+// WithFIFOPinLimit set the fraction of capacity that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithFIFOPinLimit(fraction float64) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithFIFOOnRemoval receives every evicted, expired, deleted or replaced entry, it is called while the cache is locked and must not call any method of the cache.
+//
+// A value referenced by a Handle is passed when its last Handle is released.
+func WithFIFOOnRemoval(listener RemovalListener) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		o.onRemoval = listener
+	})
+}
+
+// WithFIFOClock set the source of time and of the sweeper ticker, nil is SystemClock
+func WithFIFOClock(clock Clock) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		o.clock = clock
+	})
+}
+
+// WithFIFOMissRatioCurve enable MissRatioCurve, it samples at most samples keys of the reads, 0 disables it, it must not be negative.
+// The memory used is proportional to samples, eg. 8192 is usually accurate enough.
+func WithFIFOMissRatioCurve(samples int) FIFOOption {
+	return newFuncFIFOOption(func(o *fifoOptions) {
+		if checkMissRatioSamples(&o.optionErrors, samples) {
+			o.mrcSamples = samples
+		}
+	})
+}
@@ -9,7 +9,7 @@ import (
 
 func TestFIFO(t *testing.T) {
 	// fifo
-	var l gcache.Cache
+	var l fullCache
 	l = gcache.NewFIFO(
 		gcache.WithFIFOCapacity(3),
 	)
@@ -0,0 +1,37 @@
+//go:build go1.18
+// +build go1.18
+
+package gcache_test
+
+import (
+	"testing"
+)
+
+func fuzzModel(f *testing.F, name string) {
+	var mc modelCase
+	for _, c := range modelCases {
+		if c.name == name {
+			mc = c
+		}
+	}
+	f.Add([]byte{0, 1, 0, 2, 4, 1, 7, 3, 10, 2, 8, 1})
+	f.Add([]byte{0, 1, 0, 2, 0, 3, 0, 4, 0, 5, 0, 6, 0, 7, 0, 8, 0, 9, 4, 1, 4, 1, 13, 0})
+	f.Add([]byte{0, 1, 11, 1, 0, 2, 11, 2, 0, 3, 0, 4, 0, 5, 0, 6, 0, 7, 0, 8, 0, 9, 12, 1, 15, 0})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		if e := runModel(mc, ops); e != nil {
+			t.Fatal(e)
+		}
+	})
+}
+func FuzzLRU(f *testing.F) {
+	fuzzModel(f, `lru`)
+}
+func FuzzFIFO(f *testing.F) {
+	fuzzModel(f, `fifo`)
+}
+func FuzzLFU(f *testing.F) {
+	fuzzModel(f, `lfu`)
+}
+func FuzzLRUK(f *testing.F) {
+	fuzzModel(f, `lruk`)
+}
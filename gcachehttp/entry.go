@@ -0,0 +1,275 @@
+package gcachehttp
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+// entry a cached response
+type entry struct {
+	status int
+	header http.Header
+	body   []byte
+	// stored when the response was generated by the origin, its Age header is subtracted
+	stored time.Time
+	// lifetime the response is fresh while its age is less than lifetime
+	lifetime time.Duration
+	// ttl the time to live in the cache, returned by ExpiryPolicy
+	ttl time.Duration
+}
+
+func (e *entry) age(now time.Time) time.Duration {
+	if age := now.Sub(e.stored); age > 0 {
+		return age
+	}
+	return 0
+}
+
+// validator reports whether the response can be revalidated by a conditional request
+func (e *entry) validator() bool {
+	return e.header.Get(`ETag`) != `` || e.header.Get(`Last-Modified`) != ``
+}
+
+// variants is saved under the primary key of a response that has a Vary header,
+// the responses are saved under the keys that include the values of the vary request headers.
+type variants struct {
+	vary []string
+	ttl  time.Duration
+	// generation is part of the variant keys, the responses saved before the primary key was removed are never selected again
+	generation uint64
+	// keys the variant keys saved, they are removed with the primary key
+	keys []interface{}
+}
+
+// variantKey returns the key of the response selected by the vary request headers of r
+func variantKey(key string, v *variants, r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	b.WriteByte('\n')
+	b.WriteString(strconv.FormatUint(v.generation, 10))
+	for _, name := range v.vary {
+		b.WriteByte('\n')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(r.Header.Values(name), `,`))
+	}
+	return b.String()
+}
+
+// varyHeaders returns the sorted canonical names of the Vary header, star is true for Vary: *
+func varyHeaders(header http.Header) (vary []string, star bool) {
+	for _, value := range header.Values(`Vary`) {
+		for _, name := range strings.Split(value, `,`) {
+			name = strings.TrimSpace(name)
+			if name == `*` {
+				star = true
+				return
+			} else if name != `` {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(vary)
+	return
+}
+
+// cacheControl the directives of Cache-Control, the names are lower case
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, value := range header.Values(`Cache-Control`) {
+		for _, directive := range strings.Split(value, `,`) {
+			directive = strings.TrimSpace(directive)
+			if directive == `` {
+				continue
+			}
+			name, arg := directive, ``
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, arg = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+	return cc
+}
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+// seconds returns the delta-seconds argument of the directive
+func (cc cacheControl) seconds(name string) (d time.Duration, ok bool) {
+	arg, exists := cc[name]
+	if !exists {
+		return
+	}
+	return parseSeconds(arg)
+}
+func parseSeconds(s string) (d time.Duration, ok bool) {
+	n, e := strconv.ParseInt(s, 10, 64)
+	if e != nil || n < 0 {
+		return
+	}
+	if n > int64(math.MaxInt64/time.Second) {
+		n = int64(math.MaxInt64 / time.Second)
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// cacheableStatus the status codes that can be cached
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// hopHeaders are not saved, they only apply to a single connection
+var hopHeaders = []string{
+	`Connection`,
+	`Keep-Alive`,
+	`Proxy-Authenticate`,
+	`Proxy-Authorization`,
+	`Te`,
+	`Trailer`,
+	`Transfer-Encoding`,
+	`Upgrade`,
+}
+
+// newEntry returns the entry of a response received at now, nil if it must not be cached.
+//
+// The freshness lifetime is s-maxage, max-age or Expires minus Date. A response without one of them,
+// or with no-cache, is fresh for 0 and is only cached if it has an ETag or Last-Modified to be revalidated.
+func newEntry(status int, header http.Header, body []byte, now time.Time, staleFor time.Duration) *entry {
+	if !cacheableStatus[status] || header.Get(`Set-Cookie`) != `` {
+		return nil
+	}
+	if _, star := varyHeaders(header); star {
+		return nil
+	}
+	cc := parseCacheControl(header)
+	if cc.has(`no-store`) || cc.has(`private`) {
+		return nil
+	}
+
+	var lifetime time.Duration
+	if d, ok := cc.seconds(`s-maxage`); ok {
+		lifetime = d
+	} else if d, ok := cc.seconds(`max-age`); ok {
+		lifetime = d
+	} else if expires := header.Get(`Expires`); expires != `` {
+		// an invalid Expires is in the past
+		if t, e := http.ParseTime(expires); e == nil {
+			date, e := http.ParseTime(header.Get(`Date`))
+			if e != nil {
+				date = now
+			}
+			lifetime = t.Sub(date)
+		}
+	}
+	if cc.has(`no-cache`) || lifetime < 0 {
+		lifetime = 0
+	}
+	stored := now
+	if age, ok := parseSeconds(header.Get(`Age`)); ok {
+		stored = now.Add(-age)
+	}
+
+	e := &entry{
+		status:   status,
+		header:   header.Clone(),
+		body:     body,
+		stored:   stored,
+		lifetime: lifetime,
+	}
+	for _, name := range hopHeaders {
+		e.header.Del(name)
+	}
+	e.ttl = lifetime - e.age(now)
+	if e.validator() {
+		if e.ttl < 0 {
+			e.ttl = 0
+		}
+		if staleFor > 0 {
+			e.ttl += staleFor
+		}
+	}
+	if e.ttl <= 0 {
+		return nil
+	}
+	return e
+}
+
+// notModified reports whether the conditional request r is satisfied by the response header
+func notModified(r *http.Request, header http.Header) bool {
+	if match := r.Header.Get(`If-None-Match`); match != `` {
+		etag := header.Get(`ETag`)
+		if etag == `` {
+			return false
+		}
+		for _, tag := range strings.Split(match, `,`) {
+			tag = strings.TrimSpace(tag)
+			if tag == `*` || weakTag(tag) == weakTag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+	if since := r.Header.Get(`If-Modified-Since`); since != `` {
+		t, e := http.ParseTime(since)
+		if e != nil {
+			return false
+		}
+		modified, e := http.ParseTime(header.Get(`Last-Modified`))
+		return e == nil && !modified.After(t)
+	}
+	return false
+}
+
+// weakTag returns the opaque tag for the weak comparison
+func weakTag(tag string) string {
+	return strings.TrimPrefix(tag, `W/`)
+}
+
+// ExpiryPolicy removes a saved response from the cache when it is stale and can not be revalidated,
+// set it by gcache.WithExpiryPolicy. The other values never expire.
+//
+// Without it the responses stay in the cache until evicted, a stale response is never served either way.
+func ExpiryPolicy() gcache.ExpiryPolicy {
+	return expiryPolicy{}
+}
+
+type expiryPolicy struct{}
+
+func (expiryPolicy) AfterCreate(key, value interface{}) time.Duration {
+	return ttl(value)
+}
+func (expiryPolicy) AfterUpdate(key, value interface{}, remaining time.Duration) time.Duration {
+	return ttl(value)
+}
+func (expiryPolicy) AfterRead(key, value interface{}, remaining time.Duration) time.Duration {
+	return remaining
+}
+func ttl(value interface{}) time.Duration {
+	switch v := value.(type) {
+	case *entry:
+		return v.ttl
+	case *variants:
+		return v.ttl
+	}
+	return gcache.NeverExpire
+}
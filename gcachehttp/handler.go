@@ -0,0 +1,272 @@
+// Package gcachehttp provides a net/http middleware that caches responses in a gcache.Cache.
+package gcachehttp
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+// Handler caches the GET and HEAD responses of the wrapped handler as a shared cache.
+//
+// Responses are keyed by method, host, URL and the request headers named by Vary.
+// Cache-Control max-age, s-maxage, no-cache, no-store and private, Expires, ETag and Last-Modified are honoured,
+// a stale response is revalidated by a conditional request and refreshed by a 304.
+//
+// Requests with Authorization or Range, and responses with Set-Cookie or Vary: * are not cached.
+// A POST, PUT, PATCH or DELETE answered with a non-error status removes the GET and HEAD responses of its URL, with all their variants.
+// Concurrent misses of the same key are all passed to the wrapped handler.
+type Handler struct {
+	// generation the last generation of variants, atomic
+	generation uint64
+
+	cache gcache.Cache
+	next  http.Handler
+	opts  options
+}
+
+// NewHandler create a Handler that caches the responses of next in cache.
+//
+// Create cache with gcache.WithExpiryPolicy(ExpiryPolicy()) to remove the responses once they are stale.
+func NewHandler(cache gcache.Cache, next http.Handler, opt ...Option) *Handler {
+	opts := defaultOptions
+	for _, o := range opt {
+		o.apply(&opts)
+	}
+	if opts.clock == nil {
+		opts.clock = gcache.SystemClock
+	}
+	return &Handler{
+		cache: cache,
+		next:  next,
+		opts:  opts,
+	}
+}
+
+// Middleware returns a function that wraps a handler by NewHandler
+func Middleware(cache gcache.Cache, opt ...Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return NewHandler(cache, next, opt...)
+	}
+}
+
+func primaryKey(method string, r *http.Request) string {
+	return method + ` ` + r.Host + r.URL.RequestURI()
+}
+
+// ServeHTTP serves r from the cache or by the wrapped handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		// invalidated after the change, so a GET served meanwhile does not save the old response again (RFC 9111 section 4.4)
+		sw := &statusWriter{ResponseWriter: w}
+		h.next.ServeHTTP(sw, r)
+		if sw.status < http.StatusBadRequest {
+			h.remove(primaryKey(http.MethodGet, r))
+			h.remove(primaryKey(http.MethodHead, r))
+		}
+		return
+	default:
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	cc := parseCacheControl(r.Header)
+	if cc.has(`no-store`) || r.Header.Get(`Authorization`) != `` || r.Header.Get(`Range`) != `` {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	key := primaryKey(r.Method, r)
+	e := h.lookup(key, r)
+	if e != nil {
+		now := h.opts.clock.Now()
+		if h.fresh(e, cc, r, now) {
+			h.serve(w, r, e, now)
+			return
+		} else if !e.validator() {
+			e = nil
+		}
+	}
+	h.fetch(w, r, key, e)
+}
+
+// lookup returns the response saved for r, nil if not cached
+func (h *Handler) lookup(key string, r *http.Request) *entry {
+	v, exists := h.cache.Get(key)
+	if !exists {
+		return nil
+	}
+	switch v := v.(type) {
+	case *entry:
+		return v
+	case *variants:
+		if v, exists := h.cache.Get(variantKey(key, v, r)); exists {
+			e, _ := v.(*entry)
+			return e
+		}
+	}
+	return nil
+}
+
+// fresh reports whether e can be served without revalidation, the request can ask for a younger response
+func (h *Handler) fresh(e *entry, cc cacheControl, r *http.Request, now time.Time) bool {
+	if cc.has(`no-cache`) || r.Header.Get(`Pragma`) == `no-cache` {
+		return false
+	}
+	age := e.age(now)
+	if maxAge, ok := cc.seconds(`max-age`); ok && age > maxAge {
+		return false
+	}
+	return age < e.lifetime
+}
+
+// serve writes the saved response, or 304 if it satisfies the conditional request
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, e *entry, now time.Time) {
+	header := w.Header()
+	for k, v := range e.header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set(`Age`, strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	if notModified(r, e.header) {
+		header.Del(`Content-Length`)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		w.Write(e.body)
+	}
+}
+
+// fetch passes the request to the wrapped handler and saves the response if it can be cached.
+// If stale is not nil the request is conditional, a 304 refreshes and serves stale.
+func (h *Handler) fetch(w http.ResponseWriter, r *http.Request, key string, stale *entry) {
+	req := r
+	if stale != nil {
+		req = r.Clone(r.Context())
+		req.Header.Del(`If-None-Match`)
+		req.Header.Del(`If-Modified-Since`)
+		if etag := stale.header.Get(`ETag`); etag != `` {
+			req.Header.Set(`If-None-Match`, etag)
+		}
+		if modified := stale.header.Get(`Last-Modified`); modified != `` {
+			req.Header.Set(`If-Modified-Since`, modified)
+		}
+	}
+	now := h.opts.clock.Now()
+	rec := &recorder{
+		w:       w,
+		header:  make(http.Header),
+		maxBody: h.opts.maxBodySize,
+		keep: func(status int, header http.Header) bool {
+			if status == http.StatusNotModified {
+				return stale != nil
+			}
+			return newEntry(status, header, nil, now, h.opts.staleFor) != nil
+		},
+	}
+	h.next.ServeHTTP(rec, req)
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.buffering {
+		return
+	}
+
+	if rec.status == http.StatusNotModified {
+		// the headers of the 304 update the saved ones
+		header := stale.header.Clone()
+		for k, v := range rec.header {
+			if k != `Content-Length` {
+				header[k] = v
+			}
+		}
+		e := newEntry(stale.status, header, stale.body, now, h.opts.staleFor)
+		if e == nil {
+			h.remove(key)
+			e = &entry{
+				status: stale.status,
+				header: header,
+				body:   stale.body,
+				stored: now,
+			}
+		} else {
+			h.save(key, r, e)
+		}
+		h.serve(w, r, e, now)
+		return
+	}
+
+	e := newEntry(rec.status, rec.header, rec.body.Bytes(), now, h.opts.staleFor)
+	if e != nil {
+		h.save(key, r, e)
+	}
+	rec.flush()
+}
+
+// save e as the response of r
+func (h *Handler) save(key string, r *http.Request, e *entry) {
+	vary, _ := varyHeaders(e.header)
+	if len(vary) == 0 {
+		h.cache.Put(key, e)
+		return
+	}
+	v := &variants{
+		vary: vary,
+		ttl:  e.ttl,
+	}
+	if old := h.variants(key); old != nil && equalStrings(old.vary, vary) {
+		v.generation = old.generation
+		v.keys = old.keys
+	} else {
+		if old != nil {
+			h.cache.Delete(old.keys...)
+		}
+		v.generation = atomic.AddUint64(&h.generation, 1)
+	}
+	k := variantKey(key, v, r)
+	if !containsKey(v.keys, k) {
+		// copied, the saved variants may be read concurrently
+		v.keys = append(v.keys[:len(v.keys):len(v.keys)], k)
+	}
+	h.cache.Put(key, v)
+	h.cache.Put(k, e)
+}
+
+// variants returns the variants saved under the primary key, nil if not cached
+func (h *Handler) variants(key string) *variants {
+	v, _ := h.cache.Get(key)
+	vs, _ := v.(*variants)
+	return vs
+}
+
+// remove the response saved under the primary key, with all its variants
+func (h *Handler) remove(key string) {
+	if v := h.variants(key); v != nil {
+		h.cache.Delete(v.keys...)
+	}
+	h.cache.Delete(key)
+}
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+func containsKey(keys []interface{}, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
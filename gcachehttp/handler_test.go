@@ -0,0 +1,337 @@
+package gcachehttp_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachehttp"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+// origin counts the requests and answers with the headers set by the test
+type origin struct {
+	calls   int
+	header  http.Header
+	etag    string
+	body    string
+	lastReq *http.Request
+}
+
+func newOrigin(header ...string) *origin {
+	o := &origin{
+		header: make(http.Header),
+		body:   `hello`,
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		o.header.Add(header[i], header[i+1])
+	}
+	return o
+}
+func (o *origin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.calls++
+	o.lastReq = r
+	for k, v := range o.header {
+		w.Header()[k] = v
+	}
+	if o.etag != `` {
+		w.Header().Set(`ETag`, o.etag)
+		if r.Header.Get(`If-None-Match`) == o.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	fmt.Fprintf(w, `%s %d`, o.body, o.calls)
+}
+
+type fixture struct {
+	t       *testing.T
+	clock   *gcachetest.FakeClock
+	cache   *gcache.LRU
+	handler http.Handler
+}
+
+func newFixture(t *testing.T, next http.Handler, opt ...gcachehttp.Option) *fixture {
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	cache := gcache.NewLRU(
+		gcache.WithCapacity(100),
+		gcache.WithExpiryPolicy(gcachehttp.ExpiryPolicy()),
+		gcache.WithClock(clock),
+		gcache.WithClearInterval(0),
+	)
+	return &fixture{
+		t:       t,
+		clock:   clock,
+		cache:   cache,
+		handler: gcachehttp.NewHandler(cache, next, append([]gcachehttp.Option{gcachehttp.WithClock(clock)}, opt...)...),
+	}
+}
+func (f *fixture) do(method, url string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Add(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	f.handler.ServeHTTP(w, r)
+	return w
+}
+func (f *fixture) expectBody(body string, method, url string, header ...string) *httptest.ResponseRecorder {
+	w := f.do(method, url, header...)
+	assert.Equal(f.t, http.StatusOK, w.Code)
+	assert.Equal(f.t, body, w.Body.String())
+	return w
+}
+
+func TestMaxAge(t *testing.T) {
+	o := newOrigin(`Cache-Control`, `max-age=60`)
+	f := newFixture(t, o)
+
+	f.expectBody(`hello 1`, http.MethodGet, `/a`)
+	f.clock.Advance(time.Second * 30)
+	w := f.expectBody(`hello 1`, http.MethodGet, `/a`)
+	assert.Equal(t, `30`, w.Header().Get(`Age`))
+	assert.Equal(t, 1, o.calls)
+
+	// keyed by url and method
+	f.expectBody(`hello 2`, http.MethodGet, `/a?q=1`)
+	f.do(http.MethodHead, `/a`)
+	assert.Equal(t, 3, o.calls)
+	f.do(http.MethodHead, `/a`)
+	assert.Equal(t, 3, o.calls)
+
+	// stale without validators
+	f.clock.Advance(time.Second * 30)
+	f.expectBody(`hello 4`, http.MethodGet, `/a`)
+	assert.Equal(t, 4, o.calls)
+
+	// the request asks for a younger response
+	f.clock.Advance(time.Second * 10)
+	f.expectBody(`hello 5`, http.MethodGet, `/a`, `Cache-Control`, `max-age=5`)
+	f.expectBody(`hello 6`, http.MethodGet, `/a`, `Cache-Control`, `no-cache`)
+	f.expectBody(`hello 6`, http.MethodGet, `/a`)
+
+	// removed by the expiry policy
+	f.clock.Advance(time.Minute)
+	assert.False(t, f.cache.Contains(`GET example.com/a`))
+}
+
+func TestNotCached(t *testing.T) {
+	for _, header := range [][]string{
+		{},
+		{`Cache-Control`, `no-store, max-age=60`},
+		{`Cache-Control`, `private, max-age=60`},
+		{`Cache-Control`, `max-age=60`, `Set-Cookie`, `id=1`},
+		{`Cache-Control`, `max-age=60`, `Vary`, `*`},
+		{`Expires`, time.Unix(0, 0).UTC().Format(http.TimeFormat)},
+		{`Cache-Control`, `no-cache`},
+	} {
+		o := newOrigin(header...)
+		f := newFixture(t, o)
+		f.expectBody(`hello 1`, http.MethodGet, `/`)
+		f.expectBody(`hello 2`, http.MethodGet, `/`)
+		assert.Equal(t, 0, f.cache.Len(), header)
+	}
+
+	// the request bypasses the cache
+	o := newOrigin(`Cache-Control`, `max-age=60`)
+	f := newFixture(t, o)
+	f.expectBody(`hello 1`, http.MethodGet, `/`, `Cache-Control`, `no-store`)
+	f.expectBody(`hello 2`, http.MethodGet, `/`, `Authorization`, `Bearer x`)
+	f.expectBody(`hello 3`, http.MethodGet, `/`)
+	f.expectBody(`hello 3`, http.MethodGet, `/`)
+	f.do(http.MethodOptions, `/`)
+	assert.Equal(t, 4, o.calls)
+
+	// unsafe methods invalidate the url
+	f.do(http.MethodPost, `/`)
+	assert.Equal(t, 5, o.calls)
+	f.expectBody(`hello 6`, http.MethodGet, `/`)
+	f.expectBody(`hello 6`, http.MethodGet, `/`)
+
+	// too large
+	o = newOrigin(`Cache-Control`, `max-age=60`)
+	f = newFixture(t, o, gcachehttp.WithMaxBodySize(3))
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	f.expectBody(`hello 2`, http.MethodGet, `/`)
+}
+
+func TestInvalidate(t *testing.T) {
+	var (
+		status = http.StatusOK
+		f      *fixture
+		// cached is the result of a GET served while the unsafe request is handled
+		cached string
+	)
+	o := newOrigin(`Cache-Control`, `max-age=60`)
+	f = newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			o.ServeHTTP(w, r)
+			return
+		}
+		// the old response is still served until the change is done
+		cached = f.do(http.MethodGet, `/`).Body.String()
+		w.WriteHeader(status)
+	}))
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	f.do(http.MethodPut, `/`)
+	assert.Equal(t, `hello 1`, cached)
+	f.expectBody(`hello 2`, http.MethodGet, `/`)
+
+	// an error does not change the resource
+	for _, status = range []int{http.StatusBadRequest, http.StatusInternalServerError} {
+		assert.Equal(t, status, f.do(http.MethodDelete, `/`).Code)
+		f.expectBody(`hello 2`, http.MethodGet, `/`)
+	}
+	status = http.StatusNoContent
+	f.do(http.MethodDelete, `/`)
+	f.expectBody(`hello 3`, http.MethodGet, `/`)
+}
+
+func TestExpires(t *testing.T) {
+	date := time.Unix(0, 0).UTC()
+	o := newOrigin(
+		`Date`, date.Format(http.TimeFormat),
+		`Expires`, date.Add(time.Minute).Format(http.TimeFormat),
+	)
+	f := newFixture(t, o)
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	f.clock.Advance(time.Second * 59)
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	f.clock.Advance(time.Second)
+	f.expectBody(`hello 2`, http.MethodGet, `/`)
+}
+
+func TestRevalidate(t *testing.T) {
+	o := newOrigin(`Cache-Control`, `max-age=10`)
+	o.etag = `"v1"`
+	f := newFixture(t, o, gcachehttp.WithStaleFor(time.Minute))
+
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	f.clock.Advance(time.Second * 10)
+	// 304 refreshes the saved response
+	w := f.expectBody(`hello 1`, http.MethodGet, `/`)
+	assert.Equal(t, 2, o.calls)
+	assert.Equal(t, `"v1"`, o.lastReq.Header.Get(`If-None-Match`))
+	assert.Equal(t, `"v1"`, w.Header().Get(`ETag`))
+	f.clock.Advance(time.Second * 5)
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	assert.Equal(t, 2, o.calls)
+
+	// the client revalidates
+	w = f.do(http.MethodGet, `/`, `If-None-Match`, `W/"v1"`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, 2, o.calls)
+
+	// changed
+	o.etag = `"v2"`
+	f.clock.Advance(time.Second * 5)
+	f.expectBody(`hello 3`, http.MethodGet, `/`)
+	f.expectBody(`hello 3`, http.MethodGet, `/`)
+
+	// no-cache is always revalidated
+	o = newOrigin(`Cache-Control`, `no-cache`)
+	o.etag = `"v1"`
+	f = newFixture(t, o)
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	f.expectBody(`hello 1`, http.MethodGet, `/`)
+	assert.Equal(t, 2, o.calls)
+	// not kept when it can not be revalidated
+	f = newFixture(t, o, gcachehttp.WithStaleFor(0))
+	f.expectBody(`hello 3`, http.MethodGet, `/`)
+	assert.Equal(t, 0, f.cache.Len())
+}
+
+func TestLastModified(t *testing.T) {
+	modified := time.Unix(0, 0).UTC().Format(http.TimeFormat)
+	calls := 0
+	f := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(`Last-Modified`, modified)
+		if r.Header.Get(`If-Modified-Since`) == modified {
+			w.Header().Set(`Cache-Control`, `max-age=60`)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`body`))
+	}))
+	f.expectBody(`body`, http.MethodGet, `/`)
+	f.expectBody(`body`, http.MethodGet, `/`)
+	assert.Equal(t, 2, calls)
+	// the 304 made it fresh
+	f.expectBody(`body`, http.MethodGet, `/`)
+	assert.Equal(t, 2, calls)
+	w := f.do(http.MethodGet, `/`, `If-Modified-Since`, modified)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestVary(t *testing.T) {
+	calls := 0
+	f := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(`Cache-Control`, `max-age=60`)
+		w.Header().Set(`Vary`, `accept-language`)
+		w.Write([]byte(r.Header.Get(`Accept-Language`)))
+	}))
+	f.expectBody(`en`, http.MethodGet, `/`, `Accept-Language`, `en`)
+	f.expectBody(`fr`, http.MethodGet, `/`, `Accept-Language`, `fr`)
+	f.expectBody(`en`, http.MethodGet, `/`, `Accept-Language`, `en`)
+	f.expectBody(``, http.MethodGet, `/`)
+	f.expectBody(`fr`, http.MethodGet, `/`, `Accept-Language`, `fr`)
+	assert.Equal(t, 3, calls)
+}
+
+func TestVaryInvalidate(t *testing.T) {
+	calls := 0
+	f := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			return
+		}
+		calls++
+		w.Header().Set(`Cache-Control`, `max-age=60`)
+		w.Header().Set(`Vary`, `Accept-Encoding`)
+		fmt.Fprintf(w, `%s %d`, r.Header.Get(`Accept-Encoding`), calls)
+	}))
+	f.expectBody(`gzip 1`, http.MethodGet, `/`, `Accept-Encoding`, `gzip`)
+	f.expectBody(`br 2`, http.MethodGet, `/`, `Accept-Encoding`, `br`)
+	f.expectBody(`gzip 1`, http.MethodGet, `/`, `Accept-Encoding`, `gzip`)
+	f.expectBody(`br 2`, http.MethodGet, `/`, `Accept-Encoding`, `br`)
+
+	// the POST removes every variant
+	f.do(http.MethodPost, `/`)
+	assert.Equal(t, 0, f.cache.Len())
+	f.expectBody(`gzip 3`, http.MethodGet, `/`, `Accept-Encoding`, `gzip`)
+	f.expectBody(`br 4`, http.MethodGet, `/`, `Accept-Encoding`, `br`)
+	f.expectBody(`gzip 3`, http.MethodGet, `/`, `Accept-Encoding`, `gzip`)
+	f.expectBody(`br 4`, http.MethodGet, `/`, `Accept-Encoding`, `br`)
+	assert.Equal(t, 3, f.cache.Len())
+}
+
+func TestMiddleware(t *testing.T) {
+	cache := gcache.NewLFU(gcache.WithCapacity(10))
+	mux := http.NewServeMux()
+	calls := 0
+	mux.HandleFunc(`/`, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(`Cache-Control`, `public, max-age=60`)
+		w.Write([]byte(strings.Repeat(`x`, 10)))
+	})
+	s := httptest.NewServer(gcachehttp.Middleware(cache)(mux))
+	defer s.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, e := http.Get(s.URL)
+		if assert.NoError(t, e) {
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, int64(10), resp.ContentLength)
+			resp.Body.Close()
+		}
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,55 @@
+package gcachehttp
+
+import (
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+var defaultOptions = options{
+	maxBodySize: 1 << 20,
+	staleFor:    time.Minute * 10,
+}
+
+type options struct {
+	clock       gcache.Clock
+	maxBodySize int
+	staleFor    time.Duration
+}
+type Option interface {
+	apply(*options)
+}
+type funcOption struct {
+	f func(*options)
+}
+
+func (fdo *funcOption) apply(do *options) {
+	fdo.f(do)
+}
+func newFuncOption(f func(*options)) *funcOption {
+	return &funcOption{
+		f: f,
+	}
+}
+
+// WithClock set the source of time used to compute the age of the responses, nil is gcache.SystemClock
+func WithClock(clock gcache.Clock) Option {
+	return newFuncOption(func(o *options) {
+		o.clock = clock
+	})
+}
+
+// WithMaxBodySize responses with a larger body are streamed to the client and not cached, default 1MiB
+func WithMaxBodySize(size int) Option {
+	return newFuncOption(func(o *options) {
+		o.maxBodySize = size
+	})
+}
+
+// WithStaleFor a stale response that has an ETag or Last-Modified is kept this long to be revalidated, default 10m.
+// If <=0 it is removed when stale, and a response that must always be revalidated is not cached.
+func WithStaleFor(duration time.Duration) Option {
+	return newFuncOption(func(o *options) {
+		o.staleFor = duration
+	})
+}
@@ -0,0 +1,108 @@
+package gcachehttp
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// recorder buffers a response that may be cached, the others are passed to the client as they are written
+type recorder struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+	// maxBody a larger body is passed to the client and not cached
+	maxBody int
+	// keep reports whether the response may be cached, it is called by WriteHeader
+	keep func(status int, header http.Header) bool
+
+	wroteHeader bool
+	// buffering is true while the response is buffered
+	buffering bool
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+	r.buffering = r.keep(status, r.header)
+	if !r.buffering {
+		r.writeHeader()
+	}
+}
+func (r *recorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.buffering {
+		if r.body.Len()+len(b) <= r.maxBody {
+			return r.body.Write(b)
+		}
+		r.flush()
+	}
+	return r.w.Write(b)
+}
+
+// Flush passes the buffered response to the client, it will not be cached
+func (r *recorder) Flush() {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.buffering {
+		r.flush()
+	}
+	if f, ok := r.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// flush writes the header and the buffered body to the client and stops buffering
+func (r *recorder) flush() {
+	r.buffering = false
+	r.writeHeader()
+	if r.body.Len() != 0 {
+		r.w.Write(r.body.Bytes())
+		r.body.Reset()
+	}
+}
+func (r *recorder) writeHeader() {
+	header := r.w.Header()
+	for k, v := range r.header {
+		header[k] = v
+	}
+	r.w.WriteHeader(r.status)
+}
+
+// statusWriter records the final status of a response passed to the client, 0 until it is written
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the header with 200 if it is not written
+func (w *statusWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,114 @@
+// Package gcachetest provides utilities for testing code that uses gcache.
+package gcachetest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+// FakeClock is a gcache.Clock that only moves when Advance is called
+type FakeClock struct {
+	now     time.Time
+	tickers []*fakeTicker
+	m       sync.Mutex
+}
+
+// NewFakeClock create a FakeClock starting at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now: now,
+	}
+}
+
+// Now returns the time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.m.Lock()
+	now := c.now
+	c.m.Unlock()
+	return now
+}
+
+// NewTicker returns a Ticker that ticks when Advance passes its period
+func (c *FakeClock) NewTicker(d time.Duration) gcache.Ticker {
+	if d <= 0 {
+		panic(`non-positive interval for NewTicker`)
+	}
+	c.m.Lock()
+	t := &fakeTicker{
+		c:       make(chan time.Time),
+		stopped: make(chan struct{}),
+		period:  d,
+		next:    c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+	c.m.Unlock()
+	return t
+}
+
+// Advance moves the clock forward by d.
+//
+// Every ticker whose period has passed ticks once, Advance returns after the receivers of the ticks handled them,
+// so a sweeper of gcache has cleared the expired entries when Advance returns.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.m.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	var due []*fakeTicker
+	tickers := c.tickers[:0]
+	for _, t := range c.tickers {
+		if t.isStopped() {
+			continue
+		}
+		tickers = append(tickers, t)
+		if !t.next.After(now) {
+			due = append(due, t)
+			for !t.next.After(now) {
+				t.next = t.next.Add(t.period)
+			}
+		}
+	}
+	c.tickers = tickers
+	c.m.Unlock()
+
+	for _, t := range due {
+		t.tick(now)
+	}
+}
+
+type fakeTicker struct {
+	c       chan time.Time
+	stopped chan struct{}
+	once    sync.Once
+	period  time.Duration
+	next    time.Time
+}
+
+func (t *fakeTicker) Chan() <-chan time.Time {
+	return t.c
+}
+func (t *fakeTicker) Stop() {
+	t.once.Do(func() {
+		close(t.stopped)
+	})
+}
+func (t *fakeTicker) isStopped() bool {
+	select {
+	case <-t.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// tick sends now to the receiver, the second send returns only after the receiver handled the first one
+func (t *fakeTicker) tick(now time.Time) {
+	for i := 0; i < 2; i++ {
+		select {
+		case t.c <- now:
+		case <-t.stopped:
+			return
+		}
+	}
+}
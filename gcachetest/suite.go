@@ -0,0 +1,461 @@
+package gcachetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+// Config the settings a factory must apply to the cache it creates
+type Config struct {
+	// Capacity the maximum amount of data, Len must never exceed it
+	Capacity int
+	// Expiry the time to live of the entries after they are created or accessed, 0 never expires
+	Expiry time.Duration
+	// Clock the source of time, the suites expire the entries by advancing it
+	Clock gcache.Clock
+}
+
+// SuiteOption changes the expectations of the suites for a cache that differs from the documented semantics on purpose
+type SuiteOption interface {
+	apply(*suiteOptions)
+}
+type suiteOptions struct {
+	admission int
+}
+type funcSuiteOption struct {
+	f func(*suiteOptions)
+}
+
+func (fdo *funcSuiteOption) apply(do *suiteOptions) {
+	fdo.f(do)
+}
+func newFuncSuiteOption(f func(*suiteOptions)) *funcSuiteOption {
+	return &funcSuiteOption{
+		f: f,
+	}
+}
+
+// WithAdmission a new key only caches its value when it is accessed for the accesses time, default 1.
+//
+// Set it to k for a LowLRUK or LRUK whose history only saves keys. Before a new key is put or added,
+// the suites delete it to forget the accesses of the previous checks, then put it accesses-1 times.
+func WithAdmission(accesses int) SuiteOption {
+	return newFuncSuiteOption(func(o *suiteOptions) {
+		o.admission = accesses
+	})
+}
+
+func newSuiteOptions(opt []SuiteOption) suiteOptions {
+	opts := suiteOptions{
+		admission: 1,
+	}
+	for _, o := range opt {
+		o.apply(&opts)
+	}
+	return opts
+}
+
+// admit prepares the absent key, so the next Put or Add caches its value
+func (o *suiteOptions) admit(del func(key ...interface{}) int, put func(key, value interface{}), key, value interface{}) {
+	if o.admission < 2 {
+		return
+	}
+	del(key)
+	for i := 1; i < o.admission; i++ {
+		put(key, value)
+	}
+}
+
+// LowCacheFactory create the LowCache tested by RunLowCacheSuite
+type LowCacheFactory func(conf Config) gcache.LowCache
+
+// CacheFactory create the Cache tested by RunCacheSuite
+type CacheFactory func(conf Config) gcache.Cache
+
+const (
+	suiteCapacity = 8
+	suiteExpiry   = time.Minute
+)
+
+func newConfig(expiry time.Duration) (conf Config, clock *FakeClock) {
+	clock = NewFakeClock(time.Unix(1000, 0))
+	conf = Config{
+		Capacity: suiteCapacity,
+		Expiry:   expiry,
+		Clock:    clock,
+	}
+	return
+}
+
+// RunLowCacheSuite checks that the LowCache created by factory follows the documented semantics of LowCache.
+//
+// Every Put that removes a live entry other than the key being put must report it,
+// a LowLRUK whose history only saves keys passes with WithAdmission.
+// Peek and Contains are checked if the LowCache implements gcache.Peeker, Range if it implements gcache.Ranger.
+func RunLowCacheSuite(t *testing.T, factory LowCacheFactory, opt ...SuiteOption) {
+	opts := newSuiteOptions(opt)
+	admit := func(c gcache.LowCache, key, value interface{}) {
+		opts.admit(c.Delete, func(key, value interface{}) {
+			c.Put(key, value)
+		}, key, value)
+	}
+	t.Run(`Add`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		admit(c, 1, 1)
+		if !c.Add(1, 1) {
+			t.Fatal(`Add(1, 1) = false, want true for an absent key`)
+		}
+		if c.Add(1, 2) {
+			t.Error(`Add(1, 2) = true, want false for an existing key`)
+		}
+		expectValue(t, c.Get, 1, 1)
+		expectLen(t, c.Len, 1)
+	})
+	t.Run(`Put`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		admit(c, 1, 1)
+		if delkey, delval, deleted := c.Put(1, 1); deleted {
+			t.Errorf(`Put(1, 1) = (%v, %v, true), want nothing deleted in an empty cache`, delkey, delval)
+		}
+		delkey, delval, deleted := c.Put(1, 2)
+		if !deleted || delkey != 1 || delval != 1 {
+			t.Errorf(`Put(1, 2) = (%v, %v, %v), want the replaced (1, 1, true)`, delkey, delval, deleted)
+		}
+		expectValue(t, c.Get, 1, 2)
+		expectPeek(t, c, 1, 2)
+		expectLen(t, c.Len, 1)
+	})
+	t.Run(`Evict`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		put := make(map[interface{}]interface{})
+		evicted := make(map[interface{}]bool)
+		for i := 0; i < conf.Capacity*4; i++ {
+			admit(c, i, i*10)
+			delkey, delval, deleted := c.Put(i, i*10)
+			put[i] = i * 10
+			if deleted {
+				if delkey == i {
+					t.Fatalf(`Put(%v) reported itself as evicted`, i)
+				} else if v, ok := put[delkey]; !ok || v != delval {
+					t.Fatalf(`Put(%v) evicted (%v, %v) which was never put`, i, delkey, delval)
+				} else if evicted[delkey] {
+					t.Fatalf(`Put(%v) evicted %v twice`, i, delkey)
+				} else if p, ok := c.(gcache.Peeker); ok && p.Contains(delkey) {
+					t.Fatalf(`Put(%v) evicted %v but it is still cached`, i, delkey)
+				}
+				evicted[delkey] = true
+			}
+			if n := c.Len(); n > conf.Capacity {
+				t.Fatalf(`Len() = %v after Put(%v), want <= %v`, n, i, conf.Capacity)
+			}
+		}
+		cached := 0
+		for key, value := range put {
+			if v, exists := peek(c)(key); exists {
+				cached++
+				if v != value {
+					t.Errorf(`Peek(%v) = %v, want %v`, key, v, value)
+				}
+			} else if !evicted[key] {
+				t.Errorf(`%v was removed without being reported by Put`, key)
+			}
+		}
+		expectLen(t, c.Len, cached)
+		if cached+len(evicted) != len(put) {
+			t.Errorf(`%v cached and %v evicted, want %v in total`, cached, len(evicted), len(put))
+		}
+	})
+	t.Run(`Delete`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		for i := 0; i < 4; i++ {
+			admit(c, i, i)
+			c.Put(i, i)
+		}
+		if changed := c.Delete(0, 1, 100); changed != 2 {
+			t.Errorf(`Delete(0, 1, 100) = %v, want 2`, changed)
+		}
+		if changed := c.Delete(0); changed != 0 {
+			t.Errorf(`Delete(0) = %v, want 0 for a deleted key`, changed)
+		}
+		expectAbsent(t, c, 0)
+		expectValue(t, c.Get, 2, 2)
+		expectLen(t, c.Len, 2)
+		admit(c, 0, 10)
+		if !c.Add(0, 10) {
+			t.Error(`Add(0, 10) = false, want true after Delete`)
+		}
+	})
+	t.Run(`Expiry`, func(t *testing.T) {
+		conf, clock := newConfig(suiteExpiry)
+		c := factory(conf)
+		admit(c, 1, 1)
+		c.Put(1, 1)
+		admit(c, 2, 2)
+		c.Put(2, 2)
+		clock.Advance(conf.Expiry - 1)
+		expectPeek(t, c, 1, 1)
+		clock.Advance(1)
+		expectAbsent(t, c, 1)
+		admit(c, 1, 10)
+		if !c.Add(1, 10) {
+			t.Error(`Add(1, 10) = false, want true for an expired key`)
+		}
+		expectPeek(t, c, 1, 10)
+		admit(c, 2, 20)
+		if _, _, deleted := c.Put(2, 20); deleted {
+			t.Error(`Put(2, 20) reported the expired value as replaced`)
+		}
+		expectPeek(t, c, 2, 20)
+
+		clock.Advance(conf.Expiry)
+		admit(c, 3, 3)
+		c.Put(3, 3)
+		c.ClearExpired()
+		expectLen(t, c.Len, 1)
+		expectAbsent(t, c, 1)
+		expectValue(t, c.Get, 3, 3)
+	})
+	t.Run(`Clear`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		for i := 0; i < conf.Capacity; i++ {
+			admit(c, i, i)
+			c.Put(i, i)
+		}
+		c.Clear()
+		expectLen(t, c.Len, 0)
+		for i := 0; i < conf.Capacity; i++ {
+			expectAbsent(t, c, i)
+		}
+		if r, ok := c.(gcache.Ranger); ok {
+			r.Range(func(key, value interface{}) bool {
+				t.Errorf(`Range visited %v after Clear`, key)
+				return true
+			})
+		}
+		admit(c, 1, 1)
+		c.Put(1, 1)
+		expectValue(t, c.Get, 1, 1)
+		expectLen(t, c.Len, 1)
+	})
+	t.Run(`Range`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		r, ok := c.(gcache.Ranger)
+		if !ok {
+			t.Skip(`the LowCache does not implement gcache.Ranger`)
+		}
+		for i := 0; i < 4; i++ {
+			admit(c, i, i*10)
+			c.Put(i, i*10)
+		}
+		visited := make(map[interface{}]bool)
+		r.Range(func(key, value interface{}) bool {
+			if visited[key] {
+				t.Errorf(`Range visited %v twice`, key)
+			}
+			visited[key] = true
+			if p, ok := c.(gcache.Peeker); ok {
+				if v, _ := p.Peek(key); v != value {
+					t.Errorf(`Range visited (%v, %v), want %v`, key, value, v)
+				}
+			}
+			return true
+		})
+		if len(visited) != c.Len() {
+			t.Errorf(`Range visited %v entries, want %v`, len(visited), c.Len())
+		}
+		count := 0
+		r.Range(func(key, value interface{}) bool {
+			count++
+			return false
+		})
+		if count != 1 {
+			t.Errorf(`Range visited %v entries after f returned false, want 1`, count)
+		}
+	})
+}
+
+// RunCacheSuite checks that the Cache created by factory follows the documented semantics of Cache
+//
+// A value must be cached by its first Put, a LRUK whose history only saves keys passes with WithAdmission.
+// Peek and Contains are checked if the Cache implements gcache.Peeker, Keys if it implements gcache.Lister.
+func RunCacheSuite(t *testing.T, factory CacheFactory, opt ...SuiteOption) {
+	opts := newSuiteOptions(opt)
+	admit := func(c gcache.Cache, key, value interface{}) {
+		opts.admit(c.Delete, c.Put, key, value)
+	}
+	t.Run(`Add`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		admit(c, 1, 1)
+		if !c.Add(1, 1) {
+			t.Fatal(`Add(1, 1) = false, want true for an absent key`)
+		}
+		if c.Add(1, 2) {
+			t.Error(`Add(1, 2) = true, want false for an existing key`)
+		}
+		expectValue(t, c.Get, 1, 1)
+		expectLen(t, c.Len, 1)
+	})
+	t.Run(`Put`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		admit(c, 1, 1)
+		c.Put(1, 1)
+		c.Put(1, 2)
+		expectValue(t, c.Get, 1, 2)
+		expectPeek(t, c, 1, 2)
+		expectLen(t, c.Len, 1)
+
+		admit(c, 2, 20)
+		admit(c, 3, 30)
+		c.BatchPut(2, 20, 3, 30)
+		vals := c.BatchGet(2, 3, 4)
+		if len(vals) != 3 || vals[0].Value != 20 || vals[1].Value != 30 || vals[2].Exists {
+			t.Errorf(`BatchGet(2, 3, 4) = %v, want [20 30 absent]`, vals)
+		}
+		expectLen(t, c.Len, 3)
+	})
+	t.Run(`Evict`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		for i := 0; i < conf.Capacity*4; i++ {
+			admit(c, i, i)
+			c.Put(i, i)
+			if n := c.Len(); n > conf.Capacity {
+				t.Fatalf(`Len() = %v after Put(%v), want <= %v`, n, i, conf.Capacity)
+			}
+		}
+		if l, ok := c.(gcache.Lister); ok {
+			keys := l.Keys()
+			expectLen(t, c.Len, len(keys))
+			for _, key := range keys {
+				expectPeek(t, c, key, key)
+			}
+		}
+	})
+	t.Run(`Delete`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		for i := 0; i < 4; i++ {
+			admit(c, i, i)
+			c.Put(i, i)
+		}
+		if changed := c.Delete(0, 1, 100); changed != 2 {
+			t.Errorf(`Delete(0, 1, 100) = %v, want 2`, changed)
+		}
+		if changed := c.Delete(0); changed != 0 {
+			t.Errorf(`Delete(0) = %v, want 0 for a deleted key`, changed)
+		}
+		expectAbsent(t, c, 0)
+		expectValue(t, c.Get, 2, 2)
+		expectLen(t, c.Len, 2)
+		admit(c, 0, 10)
+		if !c.Add(0, 10) {
+			t.Error(`Add(0, 10) = false, want true after Delete`)
+		}
+	})
+	t.Run(`Expiry`, func(t *testing.T) {
+		conf, clock := newConfig(suiteExpiry)
+		c := factory(conf)
+		admit(c, 1, 1)
+		c.Put(1, 1)
+		admit(c, 2, 2)
+		c.Put(2, 2)
+		clock.Advance(conf.Expiry - 1)
+		expectPeek(t, c, 1, 1)
+		clock.Advance(1)
+		expectAbsent(t, c, 1)
+		admit(c, 1, 10)
+		if !c.Add(1, 10) {
+			t.Error(`Add(1, 10) = false, want true for an expired key`)
+		}
+		expectValue(t, c.Get, 1, 10)
+		if l, ok := c.(gcache.Lister); ok {
+			if keys := l.Keys(); len(keys) != 1 || keys[0] != 1 {
+				t.Errorf(`Keys() = %v, want the live [1]`, keys)
+			}
+		}
+	})
+	t.Run(`Clear`, func(t *testing.T) {
+		conf, _ := newConfig(0)
+		c := factory(conf)
+		for i := 0; i < conf.Capacity; i++ {
+			admit(c, i, i)
+			c.Put(i, i)
+		}
+		c.Clear()
+		expectLen(t, c.Len, 0)
+		for i := 0; i < conf.Capacity; i++ {
+			expectAbsent(t, c, i)
+		}
+		if l, ok := c.(gcache.Lister); ok {
+			if keys := l.Keys(); len(keys) != 0 {
+				t.Errorf(`Keys() = %v after Clear, want []`, keys)
+			}
+		}
+		admit(c, 1, 1)
+		c.Put(1, 1)
+		expectValue(t, c.Get, 1, 1)
+		expectLen(t, c.Len, 1)
+	})
+}
+
+type getter interface {
+	Get(key interface{}) (value interface{}, exists bool)
+}
+
+// peek returns Peek if c implements gcache.Peeker, otherwise Get which may change the order of c
+func peek(c getter) func(key interface{}) (interface{}, bool) {
+	if p, ok := c.(gcache.Peeker); ok {
+		return p.Peek
+	}
+	return c.Get
+}
+
+func expectValue(t *testing.T, get func(key interface{}) (interface{}, bool), key, value interface{}) {
+	t.Helper()
+	v, exists := get(key)
+	if !exists {
+		t.Errorf(`%v not found, want %v`, key, value)
+	} else if v != value {
+		t.Errorf(`%v = %v, want %v`, key, v, value)
+	}
+}
+
+// expectPeek checks Peek if c implements gcache.Peeker
+func expectPeek(t *testing.T, c getter, key, value interface{}) {
+	t.Helper()
+	if p, ok := c.(gcache.Peeker); ok {
+		expectValue(t, p.Peek, key, value)
+	}
+}
+
+// expectAbsent checks Get, and Peek and Contains if c implements gcache.Peeker
+func expectAbsent(t *testing.T, c getter, key interface{}) {
+	t.Helper()
+	if p, ok := c.(gcache.Peeker); ok {
+		if v, exists := p.Peek(key); exists {
+			t.Errorf(`Peek(%v) = %v, want absent`, key, v)
+		}
+		if p.Contains(key) {
+			t.Errorf(`Contains(%v) = true, want false`, key)
+		}
+	}
+	if v, exists := c.Get(key); exists {
+		t.Errorf(`Get(%v) = %v, want absent`, key, v)
+	}
+}
+func expectLen(t *testing.T, len func() int, count int) {
+	t.Helper()
+	if n := len(); n != count {
+		t.Errorf(`Len() = %v, want %v`, n, count)
+	}
+}
@@ -0,0 +1,119 @@
+package gcache
+
+// Handle keeps a value alive while it is in use, call Release when done.
+//
+// If the entry is removed while referenced, the removal listener is called by the last Release.
+type Handle interface {
+	Key() interface{}
+	Value() interface{}
+	// Release decrements the reference count, calling it more than once has no effect
+	Release()
+}
+
+// Acquirer is implemented by Cache that keep values alive while they are in use, all Cache provided by gcache implement it.
+type Acquirer interface {
+	// Acquire return a Handle of the cache value, the removal listener is not called until every Handle of it is released
+	Acquire(key interface{}) (h Handle, exists bool)
+}
+
+// reference counts the handles of an entry
+type reference struct {
+	k     interface{}
+	key   interface{}
+	value interface{}
+	count int
+	// removed is true after the entry was removed from the cache, it is no longer in refs
+	removed bool
+	reason  RemovalReason
+}
+
+type handle struct {
+	w        *wrapper
+	ref      *reference
+	released bool
+}
+
+func (h *handle) Key() interface{} {
+	return h.ref.key
+}
+func (h *handle) Value() interface{} {
+	return h.ref.value
+}
+func (h *handle) Release() {
+	w := h.w
+	w.m.Lock()
+	defer w.m.Unlock()
+	if !h.released {
+		h.released = true
+		w.release(h.ref)
+	}
+}
+
+// Acquire return a Handle of the cache value, it is counted as a Get.
+//
+// The value is not passed to the removal listener until every Handle of it is released.
+func (w *wrapper) Acquire(key interface{}) (h Handle, exists bool) {
+	k, ok := mapKey(w.keyFunc, key)
+	if !ok {
+		return
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.read(key)
+	value, exists := w.impl.Get(key)
+	if exists {
+		ref := w.refs[k]
+		if ref == nil {
+			ref = &reference{
+				k:     k,
+				key:   key,
+				value: value,
+			}
+			w.refs[k] = ref
+		}
+		ref.count++
+		h = &handle{
+			w:   w,
+			ref: ref,
+		}
+	}
+	return
+}
+func (w *wrapper) release(ref *reference) {
+	ref.count--
+	if ref.count != 0 {
+		return
+	}
+	if ref.removed {
+		if w.onRemoval != nil {
+			w.onRemoval(ref.key, ref.value, ref.reason)
+		}
+	} else {
+		delete(w.refs, ref.k)
+	}
+}
+
+// detach removes the reference of the removed entry of the normalised key k, returns false if it is not referenced
+func (w *wrapper) detach(k interface{}, reason RemovalReason) bool {
+	if len(w.refs) == 0 {
+		return false
+	}
+	ref := w.refs[k]
+	if ref == nil {
+		return false
+	}
+	delete(w.refs, k)
+	ref.removed = true
+	ref.reason = reason
+	return true
+}
+
+// namespaceHandle returns the key of the namespace
+type namespaceHandle struct {
+	Handle
+	key interface{}
+}
+
+func (h namespaceHandle) Key() interface{} {
+	return h.key
+}
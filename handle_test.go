@@ -0,0 +1,89 @@
+package gcache_test
+
+import (
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+type removal struct {
+	key, value interface{}
+	reason     gcache.RemovalReason
+}
+
+func TestAcquire(t *testing.T) {
+	var removed []removal
+	listener := func(key, value interface{}, reason gcache.RemovalReason) {
+		removed = append(removed, removal{key, value, reason})
+	}
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(2), gcache.WithLRUOnRemoval(listener)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(2), gcache.WithFIFOOnRemoval(listener)),
+		gcache.NewLFU(gcache.WithLFUCapacity(2), gcache.WithLFUOnRemoval(listener)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(2), gcache.WithLRUK(1), gcache.WithLRUKOnRemoval(listener)),
+	}
+	for _, l := range caches {
+		removed = nil
+		_, exists := l.Acquire(1)
+		assert.False(t, exists)
+
+		l.Put(1, 1)
+		h1, exists := l.Acquire(1)
+		assert.True(t, exists)
+		h2, _ := l.Acquire(1)
+		assert.Equal(t, 1, h1.Key())
+		assert.Equal(t, 1, h1.Value())
+
+		// replaced while referenced
+		l.Put(1, 10)
+		assert.Empty(t, removed)
+		h1.Release()
+		h1.Release()
+		assert.Empty(t, removed)
+		h2.Release()
+		assert.Equal(t, []removal{{1, 1, gcache.RemovalReplaced}}, removed)
+
+		// the new value is not referenced
+		h, _ := l.Acquire(1)
+		assert.Equal(t, 10, h.Value())
+		h.Release()
+		l.Delete(1)
+		assert.Equal(t, removal{1, 10, gcache.RemovalDeleted}, removed[1])
+
+		// evicted while referenced
+		removed = nil
+		l.Put(2, 2)
+		h, _ = l.Acquire(2)
+		for i := 3; i < 6; i++ {
+			l.Put(i, i)
+			l.Get(i)
+			l.Get(i)
+		}
+		assert.False(t, l.Contains(2))
+		for _, r := range removed {
+			assert.NotEqual(t, 2, r.key)
+		}
+		assert.Equal(t, 2, h.Value())
+		h.Release()
+		assert.Equal(t, removal{2, 2, gcache.RemovalEvicted}, removed[len(removed)-1])
+
+		// cleared while referenced
+		h, _ = l.Acquire(5)
+		l.Clear()
+		removed = nil
+		h.Release()
+		assert.Equal(t, []removal{{5, 5, gcache.RemovalDeleted}}, removed)
+	}
+
+	// namespace
+	removed = nil
+	l := gcache.NewLRU(gcache.WithLRUOnRemoval(listener))
+	ns := l.Namespace("a").(fullCache)
+	ns.Put(1, 1)
+	h, _ := ns.Acquire(1)
+	assert.Equal(t, 1, h.Key())
+	ns.Delete(1)
+	h.Release()
+	assert.Equal(t, []removal{{gcache.NamespacedKey{Namespace: "a", Key: 1}, 1, gcache.RemovalDeleted}}, removed)
+}
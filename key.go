@@ -0,0 +1,119 @@
+package gcache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrUnhashableKey the key cannot be used as a map key, eg. slice map or func
+var ErrUnhashableKey = errors.New(`gcache: unhashable key`)
+
+// KeyFunc normalises a key before it reaches the internal map.
+//
+// It must be deterministic, the same key must always return the same result.
+type KeyFunc func(key interface{}) interface{}
+
+// BytesKey convert []byte key to string, other keys are returned unchanged
+func BytesKey(key interface{}) interface{} {
+	if b, ok := key.([]byte); ok {
+		return string(b)
+	}
+	return key
+}
+
+// StringsKey convert []string and [][]byte key to string, other keys are returned unchanged.
+//
+// Every element is length prefixed, so []string{"a,b"} and []string{"a","b"} are different keys.
+func StringsKey(key interface{}) interface{} {
+	switch vals := key.(type) {
+	case []string:
+		var sb strings.Builder
+		for _, v := range vals {
+			sb.WriteString(strconv.Itoa(len(v)))
+			sb.WriteByte(':')
+			sb.WriteString(v)
+		}
+		return sb.String()
+	case [][]byte:
+		var sb strings.Builder
+		for _, v := range vals {
+			sb.WriteString(strconv.Itoa(len(v)))
+			sb.WriteByte(':')
+			sb.Write(v)
+		}
+		return sb.String()
+	}
+	return key
+}
+
+// TryPutter is implemented by Cache that report the keys they cannot store, all Cache provided by gcache implement it.
+type TryPutter interface {
+	// TryPut put key value to cache, returns an error wrapping ErrUnhashableKey if the key normalised by KeyFunc cannot be used as a map key
+	TryPut(key, value interface{}) error
+}
+
+// CheckKey returns an error wrapping ErrUnhashableKey if key cannot be used as a map key
+func CheckKey(key interface{}) error {
+	if isHashable(key) {
+		return nil
+	}
+	return fmt.Errorf(`%w: %T`, ErrUnhashableKey, key)
+}
+
+func isHashable(key interface{}) (ok bool) {
+	switch key.(type) {
+	case nil, string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128:
+		return true
+	}
+	t := reflect.TypeOf(key)
+	if !t.Comparable() {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Array, reflect.Interface:
+		// the dynamic type of a nested interface may still be unhashable
+		defer func() {
+			if recover() != nil {
+				ok = false
+			}
+		}()
+		_ = key == key
+	}
+	return true
+}
+
+// mapKey returns the key used by the internal map
+func mapKey(f KeyFunc, key interface{}) (interface{}, bool) {
+	if f != nil {
+		key = f(key)
+	}
+	return key, isHashable(key)
+}
+
+// keyedCache is implemented by the LowCache of gcache, it reports the keys normalised by KeyFunc when they were put.
+//
+// wrapper indexes the normalised keys, so KeyFunc never runs again on a key the caller may have modified since, eg. a reused []byte.
+type keyedCache interface {
+	// keyed reports whether the normalised keys are reported, LowLRUK needs its lru and history to implement keyedCache
+	keyed() bool
+	// setKeyedListener replace the removal listener, nil removes it
+	setKeyedListener(listener keyedListener)
+	// rangeKeyed is Range that also passes the normalised key
+	rangeKeyed(f func(k, key, value interface{}) bool)
+	// deleteKeyed is Delete by the normalised keys
+	deleteKeyed(k ...interface{}) (changed int)
+	// pinnedKeyed reports whether the entry of the normalised key is pinned
+	pinnedKeyed(k interface{}) bool
+}
+
+// isKeyed reports whether impl reports the normalised keys
+func isKeyed(impl LowCache) bool {
+	kc, ok := impl.(keyedCache)
+	return ok && kc.keyed()
+}
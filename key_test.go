@@ -0,0 +1,187 @@
+package gcache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyFunc(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUKeyFunc(gcache.BytesKey)),
+		gcache.NewFIFO(gcache.WithFIFOKeyFunc(gcache.BytesKey)),
+		gcache.NewLFU(gcache.WithLFUKeyFunc(gcache.BytesKey)),
+		gcache.NewLRUK(gcache.WithLRUK(1), gcache.WithLRUKKeyFunc(gcache.BytesKey)),
+	}
+	for _, l := range caches {
+		assert.True(t, l.Add([]byte("k"), 1))
+		assert.False(t, l.Add([]byte("k"), 2))
+		v, exists := l.Get([]byte("k"))
+		assert.True(t, exists)
+		assert.Equal(t, 1, v)
+		v, exists = l.Get("k")
+		assert.True(t, exists)
+		assert.Equal(t, 1, v)
+
+		l.Put([]byte("k"), 3)
+		v, exists = l.Get([]byte("k"))
+		assert.True(t, exists)
+		assert.Equal(t, 3, v)
+		assert.Equal(t, 1, l.Delete([]byte("k")))
+		assert.Equal(t, 0, l.Len())
+	}
+
+	l := gcache.NewLRU(gcache.WithLRUKeyFunc(gcache.StringsKey))
+	l.Put([]string{"a", "b"}, 1)
+	l.Put([]string{"a,b"}, 2)
+	assert.Equal(t, 2, l.Len())
+	v, exists := l.Get([]string{"a", "b"})
+	assert.True(t, exists)
+	assert.Equal(t, 1, v)
+	v, exists = l.Get([][]byte{[]byte("a,b")})
+	assert.True(t, exists)
+	assert.Equal(t, 2, v)
+}
+
+func TestKeyFuncModifiedKey(t *testing.T) {
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	lows := []fullLowCache{
+		full(gcache.NewLowLRU(gcache.WithCapacity(1), gcache.WithKeyFunc(gcache.BytesKey), gcache.WithExpiry(time.Minute), gcache.WithClock(clock))),
+		full(gcache.NewLowFIFO(gcache.WithCapacity(1), gcache.WithKeyFunc(gcache.BytesKey), gcache.WithExpiry(time.Minute), gcache.WithClock(clock))),
+		full(gcache.NewLowLFU(gcache.WithCapacity(1), gcache.WithKeyFunc(gcache.BytesKey), gcache.WithExpiry(time.Minute), gcache.WithClock(clock))),
+	}
+	for _, l := range lows {
+		// the caller reuses the buffer of the key
+		buf := []byte("a")
+		l.Put(buf, 1)
+		buf[0] = 'b'
+		// evicted
+		l.Put([]byte("c"), 2)
+		_, exists := l.Get([]byte("a"))
+		assert.False(t, exists)
+		assert.Equal(t, 1, l.Len())
+		assert.NoError(t, gcache.CheckInvariants(l))
+
+		// expired
+		buf = []byte("a")
+		l.Put(buf, 1)
+		buf[0] = 'b'
+		clock.Advance(time.Minute)
+		l.ClearExpired()
+		assert.Equal(t, 0, l.Len())
+		assert.NoError(t, gcache.CheckInvariants(l))
+	}
+
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithCapacity(1), gcache.WithKeyFunc(gcache.BytesKey), gcache.WithPrefixIndex(true)),
+		gcache.NewFIFO(gcache.WithCapacity(1), gcache.WithKeyFunc(gcache.BytesKey), gcache.WithPrefixIndex(true)),
+		gcache.NewLFU(gcache.WithCapacity(1), gcache.WithKeyFunc(gcache.BytesKey), gcache.WithPrefixIndex(true)),
+		gcache.NewLRUK(gcache.WithCapacity(1), gcache.WithKeyFunc(gcache.BytesKey), gcache.WithPrefixIndex(true), gcache.WithLRUKHistoryOnlyKey(false)),
+	}
+	for _, c := range caches {
+		buf := []byte("a")
+		c.PutWithTags(buf, 1, `tag`)
+		buf[0] = 'b'
+		assert.Equal(t, 1, c.InvalidateTag(`tag`))
+
+		buf = []byte("a")
+		c.Put(buf, 1)
+		buf[0] = 'b'
+		assert.Equal(t, 1, c.DeletePrefix(`a`))
+
+		buf = []byte("a")
+		c.Put(buf, 1)
+		buf[0] = 'b'
+		assert.Equal(t, 1, c.DeleteFunc(func(key, value interface{}) bool {
+			return value == 1
+		}))
+
+		// the tag of the evicted key does not delete the new value
+		buf = []byte("a")
+		c.PutWithTags(buf, 1, `tag`)
+		buf[0] = 'b'
+		c.Put([]byte("c"), 2)
+		c.Put([]byte("a"), 3)
+		assert.Equal(t, 0, c.InvalidateTag(`tag`))
+
+		ns := c.Namespace(`ns`).(fullCache)
+		buf = []byte("a")
+		ns.Put(buf, 1)
+		buf[0] = 'b'
+		ns.Clear()
+		assert.Equal(t, 0, ns.Len())
+		assert.False(t, ns.Contains([]byte("a")))
+	}
+}
+
+func TestUnhashableKey(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(),
+		gcache.NewFIFO(),
+		gcache.NewLFU(),
+		gcache.NewLRUK(),
+	}
+	type composite struct {
+		ID  int
+		Tag interface{}
+	}
+	keys := []interface{}{
+		[]byte("k"),
+		map[string]int{},
+		composite{ID: 1, Tag: []int{1}},
+	}
+	for _, l := range caches {
+		for _, key := range keys {
+			assert.False(t, l.Add(key, 1))
+			l.Put(key, 1)
+			_, exists := l.Get(key)
+			assert.False(t, exists)
+			assert.Equal(t, 0, l.Delete(key))
+			assert.Equal(t, 0, l.Len())
+
+			e := l.TryPut(key, 1)
+			assert.True(t, errors.Is(e, gcache.ErrUnhashableKey))
+			assert.Equal(t, 0, l.Len())
+			e = l.Namespace(`ns`).(fullCache).TryPut(key, 1)
+			assert.True(t, errors.Is(e, gcache.ErrUnhashableKey))
+			assert.Equal(t, e, gcache.CheckKey(key))
+			assert.Equal(t, 0, l.Len())
+		}
+		assert.NoError(t, l.TryPut("k", 1))
+		assert.NoError(t, l.Namespace(`ns`).(fullCache).TryPut("k", 2))
+	}
+
+	l := gcache.NewLRU(gcache.WithLRUKeyFunc(gcache.BytesKey))
+	assert.NoError(t, l.TryPut([]byte("k"), 1))
+	v, exists := l.Get("k")
+	assert.True(t, exists)
+	assert.Equal(t, 1, v)
+	assert.True(t, errors.Is(l.TryPut([][]byte{}, 1), gcache.ErrUnhashableKey))
+
+	loading := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		return key, nil
+	})
+	assert.True(t, errors.Is(loading.TryPut([]byte("k"), 1), gcache.ErrUnhashableKey))
+	assert.NoError(t, loading.TryPut("k", 1))
+	assert.Equal(t, 1, loading.Len())
+
+	// checked by LoadingCache if the Cache does not implement TryPutter
+	loading = gcache.NewLoadingCache(plainCache{gcache.NewLRU()}, func(key interface{}) (interface{}, error) {
+		return key, nil
+	})
+	assert.True(t, errors.Is(loading.TryPut([]byte("k"), 1), gcache.ErrUnhashableKey))
+	assert.NoError(t, loading.TryPut("k", 1))
+	v, e := loading.Get("k")
+	assert.NoError(t, e)
+	assert.Equal(t, 1, v)
+
+	for _, key := range keys {
+		assert.True(t, errors.Is(gcache.CheckKey(key), gcache.ErrUnhashableKey))
+	}
+	assert.Nil(t, gcache.CheckKey("k"))
+	assert.Nil(t, gcache.CheckKey(composite{ID: 1, Tag: "k"}))
+}
@@ -1,33 +1,57 @@
 package gcache
 
-import (
-	"runtime"
-	"time"
-)
+import "runtime"
 
 type LFU struct {
 	*wrapper
 }
 
-func NewLFU(opt ...LFUOption) (lfu *LFU) {
+// NewLFU create a lfu, it panics if an option is invalid
+func NewLFU(opt ...LFUOption) *LFU {
+	lfu, e := TryNewLFU(opt...)
+	if e != nil {
+		panic(e)
+	}
+	return lfu
+}
+
+// TryNewLFU is NewLFU but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLFU(opt ...LFUOption) (lfu *LFU, e error) {
 	opts := defaultLFUOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyLFU(&opts)
+	}
+	if e = opts.err(); e != nil {
+		return
+	}
+	opts.keyFunc = namespaceKeyFunc(opts.keyFunc)
+	if opts.clock == nil {
+		opts.clock = SystemClock
 	}
-	w := &wrapper{
-		impl: NewLowLFU(
+	w := newWrapper(
+		NewLowLFU(
 			WithLowLFUCapacity(opts.capacity),
 			WithLowLFUExpiry(opts.expiry),
+			WithLowLFUExpireAfterWrite(opts.expireAfterWrite),
+			WithLowLFUExpiryPolicy(opts.expiryPolicy),
+			WithLowLFUKeyFunc(opts.keyFunc),
+			WithLowLFUPinLimit(opts.pinLimit),
+			WithLowLFUClock(opts.clock),
 		),
-		closed: make(chan struct{}),
+		opts.keyFunc,
+		opts.prefixIndex,
+		opts.onRemoval,
+	)
+	if opts.mrcSamples > 0 {
+		w.mrc = newShards(opts.capacity, opts.mrcSamples)
 	}
 	lfu = &LFU{
 		wrapper: w,
 	}
-	if opts.expiry > 0 {
-		ticker := time.NewTicker(opts.clear)
+	if opts.clear > 0 && newExpiration(opts.expiry, opts.expireAfterWrite, opts.expiryPolicy, opts.clock).enabled() {
+		ticker := opts.clock.NewTicker(opts.clear)
 		w.ticker = ticker
-		go w.clearExpired(ticker.C)
+		go w.clearExpired(ticker.Chan())
 		runtime.SetFinalizer(lfu, (*LFU).stop)
 	}
 	return
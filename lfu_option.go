@@ -1,26 +1,29 @@
 package gcache
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var defaultLFUOptions = lfuOptions{
-	expiry:   0,
-	capacity: 1000,
-	clear:    time.Minute * 10,
+	commonOptions: commonOptions{
+		capacity: 1000,
+		pinLimit: defaultPinLimit,
+		clear:    time.Minute * 10,
+	},
 }
 
 type lfuOptions struct {
-	expiry   time.Duration
-	capacity int
-	clear    time.Duration
+	commonOptions
 }
 type LFUOption interface {
-	apply(*lfuOptions)
+	applyLFU(*lfuOptions)
 }
 type funcLFUOption struct {
 	f func(*lfuOptions)
 }
 
-func (fdo *funcLFUOption) apply(do *lfuOptions) {
+func (fdo *funcLFUOption) applyLFU(do *lfuOptions) {
 	fdo.f(do)
 }
 func newFuncLFUOption(f func(*lfuOptions)) *funcLFUOption {
@@ -29,18 +32,35 @@ func newFuncLFUOption(f func(*lfuOptions)) *funcLFUOption {
 	}
 }
 
-// WithLFUExpiry if <=0, it will not expire due to time
+// WithLFUExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity
 func WithLFUExpiry(expiry time.Duration) LFUOption {
 	return newFuncLFUOption(func(o *lfuOptions) {
 		o.expiry = expiry
 	})
 }
 
+// WithLFUExpireAfterWrite expire after write, the deadline is refreshed only by write no matter how often it is read.
+// if <=0, it will not expire due to age. If both are set the entry expires at the earlier deadline.
+func WithLFUExpireAfterWrite(expiry time.Duration) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithLFUExpiryPolicy computes a variable time to live for every entry, it overrides WithLFUExpiry.
+// WithLFUExpireAfterWrite is still applied as a hard limit.
+func WithLFUExpiryPolicy(policy ExpiryPolicy) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
 // WithLFUCapacity set the maximum amount of data to be cached
 func WithLFUCapacity(capacity int) LFUOption {
 	return newFuncLFUOption(func(o *lfuOptions) {
 		if capacity < 1 {
-			panic(`lfu capacity must > 0`)
+			o.invalid(fmt.Sprintf(`lfu capacity must > 0, got %d`, capacity))
+			return
 		}
 		o.capacity = capacity
 	})
@@ -52,3 +72,52 @@ func WithLFUClear(duration time.Duration) LFUOption {
 		po.clear = duration
 	})
 }
+
+// WithLFUKeyFunc normalises keys before they reach the internal map, eg. BytesKey StringsKey
+func WithLFUKeyFunc(f KeyFunc) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithLFUPrefixIndex index string keys in a radix tree, so DeletePrefix does not walk the whole cache
+func WithLFUPrefixIndex(enable bool) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		o.prefixIndex = enable
+	})
+}
+
+// WithLFUPinLimit set the fraction of capacity that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithLFUPinLimit(fraction float64) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithLFUOnRemoval receives every evicted, expired, deleted or replaced entry, it is called while the cache is locked and must not call any method of the cache.
+//
+// A value referenced by a Handle is passed when its last Handle is released.
+func WithLFUOnRemoval(listener RemovalListener) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		o.onRemoval = listener
+	})
+}
+
+// WithLFUClock set the source of time and of the sweeper ticker, nil is SystemClock
+func WithLFUClock(clock Clock) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		o.clock = clock
+	})
+}
+
+// WithLFUMissRatioCurve enable MissRatioCurve, it samples at most samples keys of the reads, 0 disables it, it must not be negative.
+// The memory used is proportional to samples, eg. 8192 is usually accurate enough.
+func WithLFUMissRatioCurve(samples int) LFUOption {
+	return newFuncLFUOption(func(o *lfuOptions) {
+		if checkMissRatioSamples(&o.optionErrors, samples) {
+			o.mrcSamples = samples
+		}
+	})
+}
@@ -5,11 +5,12 @@ import (
 	"time"
 
 	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestLFU(t *testing.T) {
-	var l gcache.Cache
+	var l fullCache
 	l = gcache.NewLFU(
 		gcache.WithLFUCapacity(3),
 	)
@@ -79,9 +80,11 @@ func TestLFU(t *testing.T) {
 	// expire
 	count := 3
 	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Now())
 	l = gcache.NewLFU(
 		gcache.WithLFUCapacity(count),
 		gcache.WithLFUExpiry(duration),
+		gcache.WithLFUClock(clock),
 	)
 	for i := 0; i < count; i++ {
 		l.Put(i, i)
@@ -94,7 +97,7 @@ func TestLFU(t *testing.T) {
 		assert.True(t, exists)
 		assert.Equal(t, key, val)
 	}
-	time.Sleep(duration)
+	clock.Advance(duration)
 	size := l.Len()
 	assert.Equal(t, size, count)
 	for i := 0; i < count; i++ {
@@ -105,21 +108,23 @@ func TestLFU(t *testing.T) {
 	}
 
 	// clear timer
+	clock = gcachetest.NewFakeClock(time.Now())
 	l = gcache.NewLFU(
 		gcache.WithLFUCapacity(count+1),
 		gcache.WithLFUExpiry(duration),
 		gcache.WithLFUClear(duration),
+		gcache.WithLFUClock(clock),
 	)
 	for i := 0; i < count; i++ {
 		l.Put(i, i)
 		size := l.Len()
 		assert.Equal(t, size, i+1)
 	}
-	time.Sleep(duration / 2)
+	clock.Advance(duration / 2)
 	l.Put("ok", "value")
 	size = l.Len()
 	assert.Equal(t, size, count+1)
-	time.Sleep(duration/2 + duration/3)
+	clock.Advance(duration/2 + duration/3)
 
 	size = l.Len()
 	assert.Equal(t, size, 1)
@@ -129,7 +134,7 @@ func TestLFU(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, val, "value")
 
-	time.Sleep(duration / 2)
+	clock.Advance(duration / 2)
 	size = l.Len()
 	assert.Equal(t, size, 1)
 	key = "ok"
@@ -137,7 +142,7 @@ func TestLFU(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, val, "value")
 
-	time.Sleep(duration * 2)
+	clock.Advance(duration * 2)
 	size = l.Len()
 	assert.Equal(t, size, 0)
 
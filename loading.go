@@ -0,0 +1,342 @@
+package gcache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound returned by Loader if the key does not exist in the data source
+var ErrNotFound = errors.New(`gcache: not found`)
+
+// ErrLoaderPanic is wrapped by the error returned to the waiters of a load if the Loader panics
+var ErrLoaderPanic = errors.New(`gcache: loader panic`)
+
+// Loader loads the value of key when it is missing, expired or must be refreshed.
+//
+// Return ErrNotFound or an error wrapping it if the key does not exist.
+type Loader func(key interface{}) (value interface{}, err error)
+
+// loadedValue is saved in the cache, it records when the value was loaded.
+// If err is not nil it is a tombstone, the key is cached as absent or failed.
+type loadedValue struct {
+	value  interface{}
+	err    error
+	loaded time.Time
+}
+
+type loadingCall struct {
+	wait  sync.WaitGroup
+	value interface{}
+	err   error
+	// invalidated is set if the key was put, deleted or cleared while loading, the result is not saved
+	invalidated bool
+}
+
+// LoadingCache loads missing values with a Loader, concurrent loads of the same key are deduplicated.
+//
+// Entries are saved in the wrapped Cache, so capacity and eviction are decided by it.
+// Set the expiry of the wrapped Cache longer than expiry + staleFor, or leave it unset.
+type LoadingCache struct {
+	cache  Cache
+	loader Loader
+	opts   loadingOptions
+
+	calls map[interface{}]*loadingCall
+	m     sync.Mutex
+}
+
+// NewLoadingCache create a LoadingCache that saves the values returned by loader in cache
+func NewLoadingCache(cache Cache, loader Loader, opt ...LoadingOption) *LoadingCache {
+	l, e := TryNewLoadingCache(cache, loader, opt...)
+	if e != nil {
+		panic(e)
+	}
+	return l
+}
+
+// TryNewLoadingCache is NewLoadingCache but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLoadingCache(cache Cache, loader Loader, opt ...LoadingOption) (*LoadingCache, error) {
+	opts := defaultLoadingOptions
+	for _, o := range opt {
+		o.applyLoading(&opts)
+	}
+	if e := opts.err(); e != nil {
+		return nil, e
+	}
+	if opts.clock == nil {
+		opts.clock = SystemClock
+	}
+	return &LoadingCache{
+		cache:  cache,
+		loader: loader,
+		opts:   opts,
+		calls:  make(map[interface{}]*loadingCall),
+	}, nil
+}
+
+// Get return cache value, a missing or expired value is loaded and Get waits for it.
+//
+// A value older than refreshAfter is returned immediately and reloaded in background.
+// If the reload of an expired value fails, it is still returned while within staleFor.
+// If the key is cached as absent, the cached ErrNotFound or load error is returned without calling the loader.
+func (l *LoadingCache) Get(key interface{}) (value interface{}, err error) {
+	k, ok := mapKey(l.opts.keyFunc, key)
+	if !ok {
+		err = CheckKey(k)
+		return
+	}
+	v, exists := l.cache.Get(key)
+	if !exists {
+		return l.load(k, key, false)
+	}
+	lv, ok := v.(loadedValue)
+	if !ok {
+		// put to the wrapped cache directly
+		value = v
+		return
+	}
+	age := l.opts.clock.Now().Sub(lv.loaded)
+	if lv.err != nil {
+		if age < l.tombstoneExpiry(lv.err) {
+			err = lv.err
+			return
+		}
+		return l.load(k, key, false)
+	}
+	if l.opts.expiry <= 0 || age < l.opts.expiry {
+		if l.opts.refreshAfter > 0 && age >= l.opts.refreshAfter {
+			l.refresh(k, key)
+		}
+		value = lv.value
+		return
+	}
+
+	stale := age < l.opts.expiry+l.opts.staleFor
+	value, err = l.load(k, key, stale)
+	if err != nil && stale {
+		value = lv.value
+		err = nil
+	}
+	return
+}
+
+// GetIfPresent return cache value without loading it.
+//
+// exists is false if the key is absent in cache, if it is cached as absent exists is true and err is the cached error.
+func (l *LoadingCache) GetIfPresent(key interface{}) (value interface{}, exists bool, err error) {
+	v, exists := l.cache.Get(key)
+	if !exists {
+		return
+	}
+	lv, ok := v.(loadedValue)
+	if !ok {
+		value = v
+		return
+	}
+	if l.expired(lv, l.opts.clock.Now()) {
+		exists = false
+	} else if lv.err != nil {
+		err = lv.err
+	} else {
+		value = lv.value
+	}
+	return
+}
+
+// BatchGet return cache values, missing keys are loaded.
+// Value.Err is set if the key is cached as absent or the load failed.
+func (l *LoadingCache) BatchGet(key ...interface{}) (vals []Value) {
+	vals = make([]Value, len(key))
+	for i, k := range key {
+		vals[i].Value, vals[i].Err = l.Get(k)
+		vals[i].Exists = vals[i].Err == nil
+	}
+	return
+}
+
+func (l *LoadingCache) tombstoneExpiry(err error) time.Duration {
+	if errors.Is(err, ErrNotFound) {
+		return l.opts.notFound
+	}
+	return l.opts.failed
+}
+
+// expired reports whether lv is older than its time to live at now, GetIfPresent reports it absent
+func (l *LoadingCache) expired(lv loadedValue, now time.Time) bool {
+	age := now.Sub(lv.loaded)
+	if lv.err != nil {
+		return age >= l.tombstoneExpiry(lv.err)
+	}
+	return l.opts.expiry > 0 && age >= l.opts.expiry
+}
+
+// Refresh reloads key in background, the current value is kept if the load fails
+func (l *LoadingCache) Refresh(key interface{}) {
+	k, ok := mapKey(l.opts.keyFunc, key)
+	if ok {
+		l.refresh(k, key)
+	}
+}
+func (l *LoadingCache) refresh(k, key interface{}) {
+	l.m.Lock()
+	_, loading := l.calls[k]
+	l.m.Unlock()
+	if !loading {
+		go l.load(k, key, true)
+	}
+}
+
+// load calls loader once for concurrent loads of the same key.
+// If keep is true the current value is not replaced by a tombstone when the load fails.
+func (l *LoadingCache) load(k, key interface{}, keep bool) (value interface{}, err error) {
+	l.m.Lock()
+	c, loading := l.calls[k]
+	if loading {
+		l.m.Unlock()
+		c.wait.Wait()
+		return c.value, c.err
+	}
+	c = &loadingCall{}
+	c.wait.Add(1)
+	l.calls[k] = c
+	l.m.Unlock()
+
+	defer c.wait.Done()
+	c.value, c.err = l.call(key)
+
+	// the result is saved under the lock, so Put, Delete and Clear either invalidate it or come after it
+	l.m.Lock()
+	defer l.m.Unlock()
+	delete(l.calls, k)
+	if c.invalidated {
+		return c.value, c.err
+	}
+	if c.err == nil {
+		l.cache.Put(key, loadedValue{
+			value:  c.value,
+			loaded: l.opts.clock.Now(),
+		})
+	} else if !keep {
+		if l.tombstoneExpiry(c.err) > 0 {
+			l.cache.Put(key, loadedValue{
+				err:    c.err,
+				loaded: l.opts.clock.Now(),
+			})
+		} else {
+			l.cache.Delete(key)
+		}
+	}
+	return c.value, c.err
+}
+
+// call runs the loader, a panic is returned as an error wrapping ErrLoaderPanic
+func (l *LoadingCache) call(key interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = nil
+			err = fmt.Errorf(`%w: %v`, ErrLoaderPanic, r)
+		}
+	}()
+	return l.loader(key)
+}
+
+// invalidate stops the running loads of the keys from saving their results
+func (l *LoadingCache) invalidate(key ...interface{}) {
+	l.m.Lock()
+	if len(l.calls) != 0 {
+		for _, key := range key {
+			if k, ok := mapKey(l.opts.keyFunc, key); ok {
+				if c := l.calls[k]; c != nil {
+					c.invalidated = true
+				}
+			}
+		}
+	}
+	l.m.Unlock()
+}
+
+// Put key value to cache, it is treated as just loaded and replaces the result of a running load
+func (l *LoadingCache) Put(key, value interface{}) {
+	l.invalidate(key)
+	l.cache.Put(key, loadedValue{
+		value:  value,
+		loaded: l.opts.clock.Now(),
+	})
+}
+
+// TryPut put key value to cache as Put, returns an error wrapping ErrUnhashableKey if the key cannot be used as a map key.
+// The key is checked by the KeyFunc of LoadingCache if the wrapped Cache does not implement TryPutter.
+func (l *LoadingCache) TryPut(key, value interface{}) error {
+	p, ok := l.cache.(TryPutter)
+	if !ok {
+		if k, ok := mapKey(l.opts.keyFunc, key); !ok {
+			return CheckKey(k)
+		}
+		l.Put(key, value)
+		return nil
+	}
+	l.invalidate(key)
+	return p.TryPut(key, loadedValue{
+		value:  value,
+		loaded: l.opts.clock.Now(),
+	})
+}
+
+// Delete key from cache, include tombstones. A running load of the key does not save its result.
+func (l *LoadingCache) Delete(key ...interface{}) (changed int) {
+	l.invalidate(key...)
+	return l.cache.Delete(key...)
+}
+
+// Len returns the number of cached values, tombstones and values reported absent by GetIfPresent are not included.
+//
+// It walks the whole cache, use it for monitoring not on hot paths.
+func (l *LoadingCache) Len() (count int) {
+	count, _ = l.count()
+	return
+}
+
+// Tombstones returns the number of keys cached as absent or failed, expired tombstones are not included.
+//
+// It walks the whole cache, use it for monitoring not on hot paths.
+func (l *LoadingCache) Tombstones() (count int) {
+	_, count = l.count()
+	return
+}
+
+// count walks the wrapped Cache, every entry is a value if it does not implement Ranger
+func (l *LoadingCache) count() (values, tombstones int) {
+	r, ok := l.cache.(Ranger)
+	if !ok {
+		values = l.cache.Len()
+		return
+	}
+	now := l.opts.clock.Now()
+	r.Range(func(key, value interface{}) bool {
+		lv, ok := value.(loadedValue)
+		switch {
+		case !ok:
+			values++
+		case l.expired(lv, now):
+			// reported absent by GetIfPresent
+		case lv.err != nil:
+			tombstones++
+		default:
+			values++
+		}
+		return true
+	})
+	return
+}
+
+// Clear all cached data, include tombstones. The running loads do not save their results.
+func (l *LoadingCache) Clear() {
+	l.m.Lock()
+	for _, c := range l.calls {
+		c.invalidated = true
+	}
+	l.m.Unlock()
+	l.cache.Clear()
+}
@@ -0,0 +1,86 @@
+package gcache
+
+import "time"
+
+var defaultLoadingOptions = loadingOptions{
+	expiry:       0,
+	refreshAfter: 0,
+	staleFor:     0,
+	notFound:     0,
+	failed:       0,
+}
+
+type loadingOptions struct {
+	optionErrors
+	expiry       time.Duration
+	refreshAfter time.Duration
+	staleFor     time.Duration
+	notFound     time.Duration
+	failed       time.Duration
+	keyFunc      KeyFunc
+	clock        Clock
+}
+type LoadingOption interface {
+	applyLoading(*loadingOptions)
+}
+type funcLoadingOption struct {
+	f func(*loadingOptions)
+}
+
+func (fdo *funcLoadingOption) applyLoading(do *loadingOptions) {
+	fdo.f(do)
+}
+func newFuncLoadingOption(f func(*loadingOptions)) *funcLoadingOption {
+	return &funcLoadingOption{
+		f: f,
+	}
+}
+
+// WithLoadingExpiry a loaded value expires after this duration and Get waits for the reload, if <=0 it will not expire due to time
+func WithLoadingExpiry(expiry time.Duration) LoadingOption {
+	return newFuncLoadingOption(func(o *loadingOptions) {
+		o.expiry = expiry
+	})
+}
+
+// WithLoadingRefreshAfter once a value is older than duration, Get returns it immediately and reloads it in background. if <=0 not refresh
+func WithLoadingRefreshAfter(duration time.Duration) LoadingOption {
+	return newFuncLoadingOption(func(o *loadingOptions) {
+		o.refreshAfter = duration
+	})
+}
+
+// WithLoadingStaleFor an expired value is still returned for duration if the reload fails. if <=0 not serve stale value
+func WithLoadingStaleFor(duration time.Duration) LoadingOption {
+	return newFuncLoadingOption(func(o *loadingOptions) {
+		o.staleFor = duration
+	})
+}
+
+// WithLoadingNotFoundExpiry if the loader returns ErrNotFound, the key is cached as absent for expiry. if <=0 not cached
+func WithLoadingNotFoundExpiry(expiry time.Duration) LoadingOption {
+	return newFuncLoadingOption(func(o *loadingOptions) {
+		o.notFound = expiry
+	})
+}
+
+// WithLoadingErrorExpiry if the loader returns other error, the error is cached for expiry. if <=0 not cached
+func WithLoadingErrorExpiry(expiry time.Duration) LoadingOption {
+	return newFuncLoadingOption(func(o *loadingOptions) {
+		o.failed = expiry
+	})
+}
+
+// WithLoadingKeyFunc normalises keys used to deduplicate loads, should match the KeyFunc of the cache
+func WithLoadingKeyFunc(f KeyFunc) LoadingOption {
+	return newFuncLoadingOption(func(o *loadingOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithLoadingClock set the source of time of the loaded values, nil is SystemClock
+func WithLoadingClock(clock Clock) LoadingOption {
+	return newFuncLoadingOption(func(o *loadingOptions) {
+		o.clock = clock
+	})
+}
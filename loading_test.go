@@ -0,0 +1,263 @@
+package gcache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadingCache(t *testing.T) {
+	var (
+		loads   int32
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	l := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		close(started)
+		<-release
+		return key.(int) * 10, nil
+	})
+	var wait sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			v, e := l.Get(1)
+			assert.Nil(t, e)
+			assert.Equal(t, 10, v)
+		}()
+	}
+	// the gets coming after the load saved its value are hits
+	<-started
+	close(release)
+	wait.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	assert.Equal(t, 1, l.Len())
+
+	l.Put(2, 3)
+	v, e := l.Get(2)
+	assert.Nil(t, e)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+}
+
+func TestLoadingRefresh(t *testing.T) {
+	duration := time.Millisecond * 20
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	var (
+		version int32
+		fail    int32
+		release = make(chan struct{}, 1)
+	)
+	l := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		if atomic.LoadInt32(&fail) != 0 {
+			return nil, errors.New(`db down`)
+		}
+		<-release
+		return atomic.AddInt32(&version, 1), nil
+	},
+		gcache.WithLoadingRefreshAfter(duration),
+		gcache.WithLoadingExpiry(duration*3),
+		gcache.WithLoadingStaleFor(duration*2),
+		gcache.WithLoadingClock(clock),
+	)
+	release <- struct{}{}
+	v, e := l.Get(1)
+	assert.Nil(t, e)
+	assert.Equal(t, int32(1), v)
+
+	// refresh ahead returns the current value without waiting
+	clock.Advance(duration)
+	v, e = l.Get(1)
+	assert.Nil(t, e)
+	assert.Equal(t, int32(1), v)
+	release <- struct{}{}
+	assert.Eventually(t, func() bool {
+		return gcache.LoadingCalls(l) == 0 && atomic.LoadInt32(&version) == 2
+	}, time.Second, time.Millisecond)
+	clock.Advance(duration / 2)
+	v, e = l.Get(1)
+	assert.Nil(t, e)
+	assert.Equal(t, int32(2), v)
+
+	// stale while the reload fails
+	atomic.StoreInt32(&fail, 1)
+	clock.Advance(duration * 4)
+	v, e = l.Get(1)
+	assert.Nil(t, e)
+	assert.Equal(t, int32(2), v)
+	clock.Advance(duration * 2)
+	_, e = l.Get(1)
+	assert.NotNil(t, e)
+	assert.Equal(t, 0, l.Len())
+}
+
+func TestLoadingTombstone(t *testing.T) {
+	duration := time.Millisecond * 20
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	var loads int32
+	l := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		switch key {
+		case "absent":
+			return nil, gcache.ErrNotFound
+		case "error":
+			return nil, errors.New(`db down`)
+		}
+		return key, nil
+	},
+		gcache.WithLoadingNotFoundExpiry(duration),
+		gcache.WithLoadingClock(clock),
+	)
+	_, exists, e := l.GetIfPresent("absent")
+	assert.False(t, exists)
+	assert.Nil(t, e)
+
+	for i := 0; i < 3; i++ {
+		_, e = l.Get("absent")
+		assert.True(t, errors.Is(e, gcache.ErrNotFound))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	_, exists, e = l.GetIfPresent("absent")
+	assert.True(t, exists)
+	assert.True(t, errors.Is(e, gcache.ErrNotFound))
+
+	// errors are not cached by default
+	for i := 0; i < 3; i++ {
+		_, e = l.Get("error")
+		assert.NotNil(t, e)
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&loads))
+
+	vals := l.BatchGet("ok", "absent", "error")
+	assert.True(t, vals[0].Exists)
+	assert.Equal(t, "ok", vals[0].Value)
+	assert.False(t, vals[1].Exists)
+	assert.True(t, errors.Is(vals[1].Err, gcache.ErrNotFound))
+	assert.False(t, vals[2].Exists)
+	assert.NotNil(t, vals[2].Err)
+	assert.Equal(t, 1, l.Len())
+	assert.Equal(t, 1, l.Tombstones())
+
+	clock.Advance(duration)
+	assert.Equal(t, 1, l.Len())
+	assert.Equal(t, 0, l.Tombstones())
+	_, exists, _ = l.GetIfPresent("absent")
+	assert.False(t, exists)
+	_, e = l.Get("absent")
+	assert.True(t, errors.Is(e, gcache.ErrNotFound))
+	assert.Equal(t, int32(7), atomic.LoadInt32(&loads))
+}
+
+func TestLoadingLen(t *testing.T) {
+	duration := time.Millisecond * 20
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	l := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		if key == "absent" {
+			return nil, gcache.ErrNotFound
+		}
+		return key, nil
+	},
+		gcache.WithLoadingExpiry(duration),
+		gcache.WithLoadingNotFoundExpiry(duration*2),
+		gcache.WithLoadingClock(clock),
+	)
+	l.Get(1)
+	l.Get("absent")
+	assert.Equal(t, 1, l.Len())
+	assert.Equal(t, 1, l.Tombstones())
+
+	// Len and Tombstones count what GetIfPresent reports
+	clock.Advance(duration)
+	_, exists, _ := l.GetIfPresent(1)
+	assert.False(t, exists)
+	assert.Equal(t, 0, l.Len())
+	assert.Equal(t, 1, l.Tombstones())
+	clock.Advance(duration)
+	_, exists, _ = l.GetIfPresent("absent")
+	assert.False(t, exists)
+	assert.Equal(t, 0, l.Tombstones())
+}
+
+func TestLoadingPanic(t *testing.T) {
+	var loads int32
+	l := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		if atomic.AddInt32(&loads, 1) == 1 {
+			panic(`db down`)
+		}
+		return key, nil
+	})
+	_, e := l.Get(1)
+	assert.True(t, errors.Is(e, gcache.ErrLoaderPanic))
+	assert.Equal(t, 0, gcache.LoadingCalls(l))
+	v, e := l.Get(1)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, v)
+
+	// background refresh
+	atomic.StoreInt32(&loads, 0)
+	l.Refresh(2)
+	assert.Eventually(t, func() bool {
+		return gcache.LoadingCalls(l) == 0 && atomic.LoadInt32(&loads) == 1
+	}, time.Second, time.Millisecond)
+	_, exists, _ := l.GetIfPresent(2)
+	assert.False(t, exists)
+}
+
+func TestLoadingInvalidate(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	l := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return `loaded`, nil
+	})
+	wait := func() {
+		release <- struct{}{}
+		assert.Eventually(t, func() bool {
+			return gcache.LoadingCalls(l) == 0
+		}, time.Second, time.Millisecond)
+	}
+
+	// deleted while refreshing
+	l.Put(1, `old`)
+	l.Refresh(1)
+	<-started
+	assert.Equal(t, 1, l.Delete(1))
+	wait()
+	_, exists, _ := l.GetIfPresent(1)
+	assert.False(t, exists)
+
+	// put while refreshing
+	l.Refresh(1)
+	<-started
+	l.Put(1, `new`)
+	wait()
+	v, exists, _ := l.GetIfPresent(1)
+	assert.True(t, exists)
+	assert.Equal(t, `new`, v)
+
+	// cleared while refreshing
+	l.Refresh(1)
+	<-started
+	l.Clear()
+	wait()
+	assert.Equal(t, 0, l.Len())
+
+	// not invalidated
+	l.Refresh(2)
+	<-started
+	wait()
+	v, exists, _ = l.GetIfPresent(2)
+	assert.True(t, exists)
+	assert.Equal(t, `loaded`, v)
+}
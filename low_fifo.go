@@ -2,12 +2,28 @@ package gcache
 
 // NewLowFIFO create a low-level lru, use NewFIFO unless you know exactly what you are doing.
 func NewLowFIFO(opt ...LowFIFOOption) LowCache {
+	l, e := TryNewLowFIFO(opt...)
+	if e != nil {
+		panic(e)
+	}
+	return l
+}
+
+// TryNewLowFIFO is NewLowFIFO but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLowFIFO(opt ...LowFIFOOption) (LowCache, error) {
 	opts := defaultLowFIFOOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyLowFIFO(&opts)
+	}
+	if e := opts.err(); e != nil {
+		return nil, e
 	}
-	return newLRUFIFO(false,
+	l := newLRUFIFO(false,
 		opts.capacity,
-		opts.expiry,
+		newExpiration(opts.expiry, opts.expireAfterWrite, opts.expiryPolicy, opts.clock),
+		opts.keyFunc,
+		pinLimit(opts.capacity, opts.pinLimit),
 	)
+	l.onRemoval = opts.onRemoval.keyed()
+	return l, nil
 }
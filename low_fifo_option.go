@@ -1,24 +1,28 @@
 package gcache
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var defaultLowFIFOOptions = lowFIFOOptions{
-	expiry:   0,
-	capacity: 1000,
+	commonOptions: commonOptions{
+		capacity: 1000,
+		pinLimit: defaultPinLimit,
+	},
 }
 
 type lowFIFOOptions struct {
-	expiry   time.Duration
-	capacity int
+	commonOptions
 }
 type LowFIFOOption interface {
-	apply(*lowFIFOOptions)
+	applyLowFIFO(*lowFIFOOptions)
 }
 type funcLowFIFOOption struct {
 	f func(*lowFIFOOptions)
 }
 
-func (fdo *funcLowFIFOOption) apply(do *lowFIFOOptions) {
+func (fdo *funcLowFIFOOption) applyLowFIFO(do *lowFIFOOptions) {
 	fdo.f(do)
 }
 func newFuncLowFIFOOption(f func(*lowFIFOOptions)) *funcLowFIFOOption {
@@ -27,19 +31,59 @@ func newFuncLowFIFOOption(f func(*lowFIFOOptions)) *funcLowFIFOOption {
 	}
 }
 
-// WithLowFIFOExpiry if <=0, it will not expire due to time
+// WithLowFIFOExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity
 func WithLowFIFOExpiry(expiry time.Duration) LowFIFOOption {
 	return newFuncLowFIFOOption(func(o *lowFIFOOptions) {
 		o.expiry = expiry
 	})
 }
 
+// WithLowFIFOExpireAfterWrite expire after write, the deadline is refreshed only by write no matter how often it is read.
+// if <=0, it will not expire due to age. If both are set the entry expires at the earlier deadline.
+func WithLowFIFOExpireAfterWrite(expiry time.Duration) LowFIFOOption {
+	return newFuncLowFIFOOption(func(o *lowFIFOOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithLowFIFOExpiryPolicy computes a variable time to live for every entry, it overrides WithLowFIFOExpiry.
+// WithLowFIFOExpireAfterWrite is still applied as a hard limit.
+func WithLowFIFOExpiryPolicy(policy ExpiryPolicy) LowFIFOOption {
+	return newFuncLowFIFOOption(func(o *lowFIFOOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
 // WithLowFIFOCapacity set the maximum amount of data to be cached
 func WithLowFIFOCapacity(capacity int) LowFIFOOption {
 	return newFuncLowFIFOOption(func(o *lowFIFOOptions) {
 		if capacity < 1 {
-			panic(`fifo capacity must > 0`)
+			o.invalid(fmt.Sprintf(`fifo capacity must > 0, got %d`, capacity))
+			return
 		}
 		o.capacity = capacity
 	})
 }
+
+// WithLowFIFOKeyFunc normalises keys before they reach the internal map, eg. BytesKey StringsKey
+func WithLowFIFOKeyFunc(f KeyFunc) LowFIFOOption {
+	return newFuncLowFIFOOption(func(o *lowFIFOOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithLowFIFOPinLimit set the fraction of capacity that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithLowFIFOPinLimit(fraction float64) LowFIFOOption {
+	return newFuncLowFIFOOption(func(o *lowFIFOOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithLowFIFOClock set the source of time, nil is SystemClock
+func WithLowFIFOClock(clock Clock) LowFIFOOption {
+	return newFuncLowFIFOOption(func(o *lowFIFOOptions) {
+		o.clock = clock
+	})
+}
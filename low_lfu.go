@@ -2,114 +2,337 @@ package gcache
 
 // NewLowLFU create a low-level lfu, use NewLFU unless you know exactly what you are doing.
 func NewLowLFU(opt ...LowLFUOption) LowCache {
+	l, e := TryNewLowLFU(opt...)
+	if e != nil {
+		panic(e)
+	}
+	return l
+}
+
+// TryNewLowLFU is NewLowLFU but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLowLFU(opt ...LowLFUOption) (LowCache, error) {
 	opts := defaultLowLFUOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyLowLFU(&opts)
 	}
-	if opts.expiry > 0 {
-		return newLowLFUEx(opts.capacity, opts.expiry)
+	if e := opts.err(); e != nil {
+		return nil, e
 	}
-	return newLowLFU(opts.capacity)
+	l := newLowLFU(opts.capacity,
+		newExpiration(opts.expiry, opts.expireAfterWrite, opts.expiryPolicy, opts.clock),
+		opts.keyFunc,
+		pinLimit(opts.capacity, opts.pinLimit),
+	)
+	l.onRemoval = opts.onRemoval.keyed()
+	return l, nil
 }
 
 type lowLFU struct {
 	keys     map[interface{}]lfuValue
 	hot      *lfuHeap
 	capacity int
+	expiry   expiration
+	keyFunc  KeyFunc
+	pinned   int
+	pinLimit int
+
+	onRemoval keyedListener
 }
 
-func newLowLFU(capacity int) *lowLFU {
+func newLowLFU(capacity int, expiry expiration, keyFunc KeyFunc, pinLimit int) *lowLFU {
 	return &lowLFU{
 		keys:     make(map[interface{}]lfuValue, capacity),
 		hot:      newLFUHeap(capacity),
 		capacity: capacity,
+		expiry:   expiry.withWheel(),
+		keyFunc:  keyFunc,
+		pinLimit: pinLimit,
+	}
+}
+
+// SetRemovalListener replace the listener, nil removes it
+func (l *lowLFU) SetRemovalListener(listener RemovalListener) {
+	l.onRemoval = listener.keyed()
+}
+func (l *lowLFU) notify(v cacheValue, value interface{}, reason RemovalReason) {
+	if l.onRemoval != nil {
+		l.onRemoval(v.MapKey(), v.GetKey(), value, reason)
 	}
 }
 func (l *lowLFU) ClearExpired() {
+	l.expiry.ClearExpired(l.expired)
+}
+func (l *lowLFU) expired(v cacheValue) {
+	k := v.MapKey()
+	if val, exists := l.keys[k]; exists {
+		l.remove(k, val, RemovalExpired)
+	}
+}
+func (l *lowLFU) remove(k interface{}, v lfuValue, reason RemovalReason) {
+	l.hot.Remove(v.GetIndex())
+	delete(l.keys, k)
+	l.expiry.Remove(v)
+	if v.Pinned() {
+		v.SetPinned(false)
+		l.pinned--
+	}
+	l.notify(v, v.GetValue(), reason)
 }
 
 // Add the value to the cache, only when the key does not exist
 func (l *lowLFU) Add(key, value interface{}) (added bool) {
-	_, exists := l.keys[key]
-	if !exists {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	now := l.expiry.Nano()
+	v, exists := l.keys[k]
+	if exists {
+		if l.expiry.IsDeletedAt(v, now) {
+			added = true
+			l.unpin(v)
+			l.notify(v, v.GetValue(), RemovalExpired)
+			v.SetKey(key)
+			v.SetValue(value)
+			l.moveHot(v, expiryCreate, now)
+			l.ClearExpired()
+		}
+	} else {
 		added = true
-		l.add(key, value)
+		l.add(k, key, value, now)
 	}
 	return
 }
 
-func (l *lowLFU) add(key, value interface{}) (delkey, delval interface{}, deleted bool) {
+func (l *lowLFU) add(k, key, value interface{}, now int64) (delkey, delval interface{}, deleted bool) {
 	// capacity limit reached, pop
 	if l.hot.Len() >= l.capacity {
 		deleted = true
-		v := l.hot.Remove(0)
+		// pinned are sorted after the others, pinLimit leaves at least one entry for eviction
+		v := l.hot.heap[0]
 		delkey = v.GetKey()
 		delval = v.GetValue()
-		delete(l.keys, delkey)
+		l.remove(v.MapKey(), v, RemovalEvicted)
 	}
 	// new value
-	v := newLFUValue(key, value, 0)
-	l.keys[key] = v
+	v := newLFUValue(k, key, value, l.expiry, now)
+	l.keys[k] = v
 	l.hot.Push(v)
 	return
 }
-func (l *lowLFU) moveHot(v lfuValue) {
+func (l *lowLFU) moveHot(v lfuValue, event expiryEvent, now int64) {
+	v.Touch(now, event == expiryCreate)
+	l.expiry.Apply(v, event, now)
 	v.Increment()
 	l.hot.Fix(v.GetIndex())
 }
 func (l *lowLFU) Put(key, value interface{}) (delkey, delval interface{}, deleted bool) {
-	v, exists := l.keys[key]
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	now := l.expiry.Nano()
+	v, exists := l.keys[k]
 	if exists {
-		deleted = true
-		delkey = key
-		delval = v.GetValue()
-
 		// put
-		v.SetValue(value)
-		// move hot
-		l.moveHot(v)
+		if l.expiry.IsDeletedAt(v, now) {
+			l.unpin(v)
+			l.notify(v, v.GetValue(), RemovalExpired)
+			v.SetKey(key)
+			v.SetValue(value)
+			// move hot
+			l.moveHot(v, expiryCreate, now)
+
+			l.ClearExpired()
+		} else {
+			deleted = true
+			delkey = key
+			delval = v.GetValue()
+			l.notify(v, delval, RemovalReplaced)
+
+			v.SetValue(value)
+			// move hot
+			l.moveHot(v, expiryUpdate, now)
+		}
 	} else {
-		delkey, delval, deleted = l.add(key, value)
+		delkey, delval, deleted = l.add(k, key, value, now)
 	}
 	return
 }
 
 // Get return cache value
 func (l *lowLFU) Get(key interface{}) (value interface{}, exists bool) {
-	v, exists := l.keys[key]
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	v, exists := l.keys[k]
 	if !exists {
 		return
 	}
+	now := l.expiry.Nano()
+	if l.expiry.IsDeletedAt(v, now) {
+		l.remove(k, v, RemovalExpired)
+		exists = false
+		l.ClearExpired()
+		return
+	}
 	value = v.GetValue()
 
 	// move hot
-	l.moveHot(v)
+	l.moveHot(v, expiryRead, now)
+	return
+}
+
+// Peek return cache value without incrementing the count or the deadline
+func (l *lowLFU) Peek(key interface{}) (value interface{}, exists bool) {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	v, exists := l.keys[k]
+	if !exists {
+		return
+	}
+	if l.expiry.IsDeleted(v) {
+		exists = false
+		return
+	}
+	value = v.GetValue()
+	return
+}
+
+// GetEntry return the value and metadata of key without incrementing the count or the deadline
+func (l *lowLFU) GetEntry(key interface{}) (info EntryInfo, exists bool) {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	v, exists := l.keys[k]
+	if !exists {
+		return
+	}
+	if l.expiry.IsDeleted(v) {
+		exists = false
+		return
+	}
+	info = newEntryInfo(v, `lfu`, l.expiry.Nano())
+	info.Count = v.GetCount()
+	return
+}
+
+// Pin exempts key from eviction, it is still removed by Delete, Clear and expiry
+func (l *lowLFU) Pin(key interface{}) error {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return CheckKey(k)
+	}
+	v, exists := l.keys[k]
+	if !exists || l.expiry.IsDeleted(v) {
+		return ErrNotCached
+	} else if v.Pinned() {
+		return nil
+	} else if l.pinned >= l.pinLimit {
+		return errPinLimit(l.pinLimit, l.capacity)
+	}
+	v.SetPinned(true)
+	l.pinned++
+	l.hot.Fix(v.GetIndex())
+	return nil
+}
+
+// Unpin makes key evictable again, returns false if it is not pinned
+func (l *lowLFU) Unpin(key interface{}) (unpinned bool) {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	v, exists := l.keys[k]
+	if exists {
+		unpinned = l.unpin(v)
+	}
+	return
+}
+func (l *lowLFU) unpin(v lfuValue) bool {
+	if !v.Pinned() {
+		return false
+	}
+	v.SetPinned(false)
+	l.pinned--
+	l.hot.Fix(v.GetIndex())
+	return true
+}
+
+// Contains reports whether the key is cached without incrementing the count or the deadline
+func (l *lowLFU) Contains(key interface{}) (exists bool) {
+	_, exists = l.Peek(key)
 	return
 }
 func (l *lowLFU) Delete(key ...interface{}) (changed int) {
-	var (
-		v      lfuValue
-		exists bool
-	)
-	for _, k := range key {
-		v, exists = l.keys[k]
-		if exists {
-			changed++
-			delete(l.keys, k)
-			l.hot.Remove(v.GetIndex())
+	for _, key := range key {
+		if k, ok := mapKey(l.keyFunc, key); ok {
+			changed += l.delete(k)
 		}
 	}
 	return
 }
+func (l *lowLFU) deleteKeyed(k ...interface{}) (changed int) {
+	for _, k := range k {
+		changed += l.delete(k)
+	}
+	return
+}
+func (l *lowLFU) delete(k interface{}) int {
+	v, exists := l.keys[k]
+	if !exists {
+		return 0
+	}
+	l.remove(k, v, RemovalDeleted)
+	return 1
+}
 
 func (l *lowLFU) Len() int {
 	return l.hot.Len()
 }
 
 func (l *lowLFU) Clear() {
+	if l.onRemoval != nil {
+		for _, v := range l.hot.heap {
+			l.onRemoval(v.MapKey(), v.GetKey(), v.GetValue(), RemovalDeleted)
+		}
+	}
 	l.hot.Clear()
+	l.expiry.Clear()
+	l.pinned = 0
 	for k := range l.keys {
 		delete(l.keys, k)
 	}
 	return
 }
+
+// Range calls f for each live entry from the lowest count to the highest
+func (l *lowLFU) Range(f func(key, value interface{}) bool) {
+	l.rangeKeyed(func(k, key, value interface{}) bool {
+		return f(key, value)
+	})
+}
+func (l *lowLFU) rangeKeyed(f func(k, key, value interface{}) bool) {
+	for _, v := range l.hot.Sorted() {
+		if l.expiry.IsDeleted(v) {
+			continue
+		}
+		if !f(v.MapKey(), v.GetKey(), v.GetValue()) {
+			break
+		}
+	}
+}
+func (l *lowLFU) keyed() bool {
+	return true
+}
+func (l *lowLFU) pinnedKeyed(k interface{}) bool {
+	v, exists := l.keys[k]
+	return exists && v.Pinned()
+}
+func (l *lowLFU) setKeyedListener(listener keyedListener) {
+	l.onRemoval = listener
+}
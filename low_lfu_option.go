@@ -1,24 +1,28 @@
 package gcache
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var defaultLowLFUOptions = lowLFUOptions{
-	expiry:   0,
-	capacity: 1000,
+	commonOptions: commonOptions{
+		capacity: 1000,
+		pinLimit: defaultPinLimit,
+	},
 }
 
 type lowLFUOptions struct {
-	expiry   time.Duration
-	capacity int
+	commonOptions
 }
 type LowLFUOption interface {
-	apply(*lowLFUOptions)
+	applyLowLFU(*lowLFUOptions)
 }
 type funcLowLFUOption struct {
 	f func(*lowLFUOptions)
 }
 
-func (fdo *funcLowLFUOption) apply(do *lowLFUOptions) {
+func (fdo *funcLowLFUOption) applyLowLFU(do *lowLFUOptions) {
 	fdo.f(do)
 }
 func newFuncLowLFUOption(f func(*lowLFUOptions)) *funcLowLFUOption {
@@ -27,19 +31,59 @@ func newFuncLowLFUOption(f func(*lowLFUOptions)) *funcLowLFUOption {
 	}
 }
 
-// WithLowLFUExpiry if <=0, it will not expire due to time
+// WithLowLFUExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity
 func WithLowLFUExpiry(expiry time.Duration) LowLFUOption {
 	return newFuncLowLFUOption(func(o *lowLFUOptions) {
 		o.expiry = expiry
 	})
 }
 
+// WithLowLFUExpireAfterWrite expire after write, the deadline is refreshed only by write no matter how often it is read.
+// if <=0, it will not expire due to age. If both are set the entry expires at the earlier deadline.
+func WithLowLFUExpireAfterWrite(expiry time.Duration) LowLFUOption {
+	return newFuncLowLFUOption(func(o *lowLFUOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithLowLFUExpiryPolicy computes a variable time to live for every entry, it overrides WithLowLFUExpiry.
+// WithLowLFUExpireAfterWrite is still applied as a hard limit.
+func WithLowLFUExpiryPolicy(policy ExpiryPolicy) LowLFUOption {
+	return newFuncLowLFUOption(func(o *lowLFUOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
 // WithLowLFUCapacity set the maximum amount of data to be cached
 func WithLowLFUCapacity(capacity int) LowLFUOption {
 	return newFuncLowLFUOption(func(o *lowLFUOptions) {
 		if capacity < 1 {
-			panic(`lfu capacity must > 0`)
+			o.invalid(fmt.Sprintf(`lfu capacity must > 0, got %d`, capacity))
+			return
 		}
 		o.capacity = capacity
 	})
 }
+
+// WithLowLFUKeyFunc normalises keys before they reach the internal map, eg. BytesKey StringsKey
+func WithLowLFUKeyFunc(f KeyFunc) LowLFUOption {
+	return newFuncLowLFUOption(func(o *lowLFUOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithLowLFUPinLimit set the fraction of capacity that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithLowLFUPinLimit(fraction float64) LowLFUOption {
+	return newFuncLowLFUOption(func(o *lowLFUOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithLowLFUClock set the source of time, nil is SystemClock
+func WithLowLFUClock(clock Clock) LowLFUOption {
+	return newFuncLowLFUOption(func(o *lowLFUOptions) {
+		o.clock = clock
+	})
+}
@@ -2,7 +2,7 @@ package gcache
 
 import (
 	"container/heap"
-	"time"
+	"sort"
 )
 
 type lfuValue interface {
@@ -14,23 +14,30 @@ type lfuValue interface {
 	GetIndex() int
 }
 
-func newLFUValue(key, val interface{}, expiry time.Duration) lfuValue {
-	if expiry > 0 {
-		return &deadlineLFUValue{
+func newLFUValue(k, key, val interface{}, e expiration, now int64) lfuValue {
+	if e.enabled() {
+		v := &deadlineLFUValue{
 			baseLFUValue: baseLFUValue{
 				baseValue: baseValue{
-					key:   key,
-					value: val,
+					k:        k,
+					key:      key,
+					value:    val,
+					created:  now,
+					accessed: now,
 				},
 				count: 1,
 			},
-			deadline: time.Now().Add(expiry),
 		}
+		e.Apply(v, expiryCreate, now)
+		return v
 	}
 	return &baseLFUValue{
 		baseValue: baseValue{
-			key:   key,
-			value: val,
+			k:        k,
+			key:      key,
+			value:    val,
+			created:  now,
+			accessed: now,
 		},
 		count: 1,
 	}
@@ -60,14 +67,23 @@ func (v *baseLFUValue) Increment() {
 
 type deadlineLFUValue struct {
 	baseLFUValue
-	deadline time.Time
+	deadline int64
+	expire   int64
+	timer    timerNode
 }
 
-func (v *deadlineLFUValue) IsDeleted() bool {
-	return !v.deadline.After(time.Now())
+func (v *deadlineLFUValue) IsDeleted(now int64) bool {
+	return v.deadline != 0 && v.deadline <= now
 }
-func (v *deadlineLFUValue) SetDeadline(deadline time.Time) {
+func (v *deadlineLFUValue) GetDeadline() (deadline, expire int64) {
+	return v.deadline, v.expire
+}
+func (v *deadlineLFUValue) SetDeadline(deadline, expire int64) {
 	v.deadline = deadline
+	v.expire = expire
+}
+func (v *deadlineLFUValue) Timer() *timerNode {
+	return &v.timer
 }
 
 type lfuValueHeap []lfuValue
@@ -81,7 +97,15 @@ func (a lfuValueHeap) Swap(i, j int) {
 	a[j].SetIndex(j)
 }
 func (a lfuValueHeap) Less(i, j int) bool {
-	return a[i].GetCount() < a[j].GetCount()
+	return lfuLess(a[i], a[j])
+}
+
+// lfuLess sorts by count, pinned values are sorted after the others
+func lfuLess(a, b lfuValue) bool {
+	if a.Pinned() != b.Pinned() {
+		return b.Pinned()
+	}
+	return a.GetCount() < b.GetCount()
 }
 func (h *lfuValueHeap) Push(x interface{}) {
 	v := x.(lfuValue)
@@ -125,6 +149,16 @@ func (h *lfuHeap) Remove(i int) lfuValue {
 func (h *lfuHeap) Fix(i int) {
 	heap.Fix(&h.heap, i)
 }
+
+// Sorted returns a copy of the values in eviction order, the first is the next to be evicted
+func (h *lfuHeap) Sorted() []lfuValue {
+	vals := make([]lfuValue, len(h.heap))
+	copy(vals, h.heap)
+	sort.SliceStable(vals, func(i, j int) bool {
+		return lfuLess(vals[i], vals[j])
+	})
+	return vals
+}
 func (h *lfuHeap) Clear() {
 	for i := 0; i < len(h.heap); i++ {
 		h.heap[i] = nil
@@ -2,12 +2,28 @@ package gcache
 
 // NewLowLRU create a low-level lru, use NewLRU unless you know exactly what you are doing.
 func NewLowLRU(opt ...LowLRUOption) LowCache {
+	l, e := TryNewLowLRU(opt...)
+	if e != nil {
+		panic(e)
+	}
+	return l
+}
+
+// TryNewLowLRU is NewLowLRU but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLowLRU(opt ...LowLRUOption) (LowCache, error) {
 	opts := defaultLowLRUOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyLowLRU(&opts)
+	}
+	if e := opts.err(); e != nil {
+		return nil, e
 	}
-	return newLRUFIFO(true,
+	l := newLRUFIFO(true,
 		opts.capacity,
-		opts.expiry,
+		newExpiration(opts.expiry, opts.expireAfterWrite, opts.expiryPolicy, opts.clock),
+		opts.keyFunc,
+		pinLimit(opts.capacity, opts.pinLimit),
 	)
+	l.onRemoval = opts.onRemoval.keyed()
+	return l, nil
 }
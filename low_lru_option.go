@@ -1,24 +1,28 @@
 package gcache
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var defaultLowLRUOptions = lowLRUOptions{
-	expiry:   0,
-	capacity: 1000,
+	commonOptions: commonOptions{
+		capacity: 1000,
+		pinLimit: defaultPinLimit,
+	},
 }
 
 type lowLRUOptions struct {
-	expiry   time.Duration
-	capacity int
+	commonOptions
 }
 type LowLRUOption interface {
-	apply(*lowLRUOptions)
+	applyLowLRU(*lowLRUOptions)
 }
 type funcLowLRUOption struct {
 	f func(*lowLRUOptions)
 }
 
-func (fdo *funcLowLRUOption) apply(do *lowLRUOptions) {
+func (fdo *funcLowLRUOption) applyLowLRU(do *lowLRUOptions) {
 	fdo.f(do)
 }
 func newFuncLowLRUOption(f func(*lowLRUOptions)) *funcLowLRUOption {
@@ -27,19 +31,59 @@ func newFuncLowLRUOption(f func(*lowLRUOptions)) *funcLowLRUOption {
 	}
 }
 
-// WithLowLRUExpiry if <=0, it will not expire due to time
+// WithLowLRUExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity
 func WithLowLRUExpiry(expiry time.Duration) LowLRUOption {
 	return newFuncLowLRUOption(func(o *lowLRUOptions) {
 		o.expiry = expiry
 	})
 }
 
+// WithLowLRUExpireAfterWrite expire after write, the deadline is refreshed only by write no matter how often it is read.
+// if <=0, it will not expire due to age. If both are set the entry expires at the earlier deadline.
+func WithLowLRUExpireAfterWrite(expiry time.Duration) LowLRUOption {
+	return newFuncLowLRUOption(func(o *lowLRUOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithLowLRUExpiryPolicy computes a variable time to live for every entry, it overrides WithLowLRUExpiry.
+// WithLowLRUExpireAfterWrite is still applied as a hard limit.
+func WithLowLRUExpiryPolicy(policy ExpiryPolicy) LowLRUOption {
+	return newFuncLowLRUOption(func(o *lowLRUOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
 // WithLRUCapacity set the maximum amount of data to be cached
 func WithLowLRUCapacity(capacity int) LowLRUOption {
 	return newFuncLowLRUOption(func(o *lowLRUOptions) {
 		if capacity < 1 {
-			panic(`lru capacity must > 0`)
+			o.invalid(fmt.Sprintf(`lru capacity must > 0, got %d`, capacity))
+			return
 		}
 		o.capacity = capacity
 	})
 }
+
+// WithLowLRUKeyFunc normalises keys before they reach the internal map, eg. BytesKey StringsKey
+func WithLowLRUKeyFunc(f KeyFunc) LowLRUOption {
+	return newFuncLowLRUOption(func(o *lowLRUOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithLowLRUPinLimit set the fraction of capacity that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithLowLRUPinLimit(fraction float64) LowLRUOption {
+	return newFuncLowLRUOption(func(o *lowLRUOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithLowLRUClock set the source of time, nil is SystemClock
+func WithLowLRUClock(clock Clock) LowLRUOption {
+	return newFuncLowLRUOption(func(o *lowLRUOptions) {
+		o.clock = clock
+	})
+}
@@ -1,31 +1,133 @@
 package gcache
 
+import "time"
+
 type kValue struct {
 	Count int
 	Key   interface{}
 	Value interface{}
 }
 
+// historyExpiry passes the value saved in kValue to ExpiryPolicy
+type historyExpiry struct {
+	policy ExpiryPolicy
+}
+
+func newHistoryExpiry(policy ExpiryPolicy) ExpiryPolicy {
+	if policy == nil {
+		return nil
+	}
+	return historyExpiry{
+		policy: policy,
+	}
+}
+func (e historyExpiry) AfterCreate(key, value interface{}) time.Duration {
+	return e.policy.AfterCreate(key, value.(kValue).Value)
+}
+func (e historyExpiry) AfterUpdate(key, value interface{}, remaining time.Duration) time.Duration {
+	return e.policy.AfterUpdate(key, value.(kValue).Value, remaining)
+}
+func (e historyExpiry) AfterRead(key, value interface{}, remaining time.Duration) time.Duration {
+	return e.policy.AfterRead(key, value.(kValue).Value, remaining)
+}
+
 // A low-level implementation of lruk, use LRUK unless you know exactly what you are doing.
 type LowLRUK struct {
 	opts         lowLRUKOptions
 	history, lru LowCache
+
+	onRemoval keyedListener
+	// promoting is true while a key is moved from history to lru
+	promoting bool
+	// replacing is true while Put replaces the value saved in history, until historyRemoved reports it
+	replacing bool
 }
 
 // NewLowLRUK create a low-level lru, use NewLRUK unless you know exactly what you are doing.
 func NewLowLRUK(history, lru LowCache, opt ...LowLRUKOption) *LowLRUK {
+	l, e := TryNewLowLRUK(history, lru, opt...)
+	if e != nil {
+		panic(e)
+	}
+	return l
+}
+
+// TryNewLowLRUK is NewLowLRUK but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLowLRUK(history, lru LowCache, opt ...LowLRUKOption) (*LowLRUK, error) {
 	opts := defaultLowLRUKOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyLowLRUK(&opts)
+	}
+	if e := opts.err(); e != nil {
+		return nil, e
 	}
 	if opts.k < 2 {
 		history = nil
 	}
-	return &LowLRUK{
+	l := &LowLRUK{
 		opts:    opts,
 		lru:     lru,
 		history: history,
 	}
+	if opts.onRemoval != nil {
+		l.SetRemovalListener(opts.onRemoval)
+	}
+	return l, nil
+}
+
+// SetRemovalListener replace the listener, nil removes it.
+// It is installed on the lru and history that implement RemovalNotifier,
+// history is reported only if not historyOnlyKey.
+func (l *LowLRUK) SetRemovalListener(listener RemovalListener) {
+	l.setKeyedListener(listener.keyed())
+}
+func (l *LowLRUK) setKeyedListener(listener keyedListener) {
+	l.onRemoval = listener
+	setListener(l.lru, listener)
+	if l.history != nil {
+		if listener == nil {
+			setListener(l.history, nil)
+		} else {
+			setListener(l.history, l.historyRemoved)
+		}
+	}
+}
+func (l *LowLRUK) keyed() bool {
+	return isKeyed(l.lru) && (l.history == nil || isKeyed(l.history))
+}
+func (l *LowLRUK) historyRemoved(k, key, value interface{}, reason RemovalReason) {
+	if l.replacing {
+		// Put replaced the value or moved the key to lru with a new value
+		l.replacing = false
+		l.onRemoval(k, key, value.(kValue).Value, RemovalReplaced)
+		return
+	}
+	// the count was updated or moved to lru
+	if l.opts.historyOnlyKey || l.promoting || reason == RemovalReplaced {
+		return
+	}
+	l.onRemoval(k, key, value.(kValue).Value, reason)
+}
+
+// replace calls f that replaces the value kv saved in history or promotes the key to lru,
+// the listener is notified by historyRemoved with the normalised key.
+func (l *LowLRUK) replace(key interface{}, kv kValue, f func()) {
+	if l.opts.historyOnlyKey || l.onRemoval == nil {
+		f()
+		return
+	}
+	l.replacing = true
+	f()
+	if l.replacing {
+		// history does not implement RemovalNotifier
+		l.replacing = false
+		l.onRemoval(key, key, kv.Value, RemovalReplaced)
+	}
+}
+func (l *LowLRUK) promote(key interface{}) {
+	l.promoting = true
+	l.history.Delete(key)
+	l.promoting = false
 }
 
 // Clear Expired cache
@@ -36,7 +138,10 @@ func (l *LowLRUK) ClearExpired() {
 	}
 }
 
-// Add the value to the cache, only when the key does not exist
+// Add the value to the cache, only when the key does not exist.
+//
+// If historyOnlyKey is false a value saved in history exists, Add returns false without counting an access.
+// Otherwise the history only counts the access, the value is added to lru when the key reaches k accesses.
 func (l *LowLRUK) Add(key, value interface{}) (added bool) {
 	_, exists := l.lru.Get(key)
 	if exists {
@@ -48,10 +153,14 @@ func (l *LowLRUK) Add(key, value interface{}) (added bool) {
 
 	v, exists := l.history.Get(key)
 	if exists {
+		if !l.opts.historyOnlyKey {
+			// the value is cached in history
+			return
+		}
 		kv := v.(kValue)
 		kv.Count++
 		if kv.Count >= l.opts.k {
-			l.history.Delete(key)
+			l.promote(key)
 			added = l.lru.Add(key, value)
 		} else {
 			l.history.Put(key, kv)
@@ -70,7 +179,11 @@ func (l *LowLRUK) Add(key, value interface{}) (added bool) {
 	return
 }
 
-// Put key value to cache
+// Put key value to cache.
+//
+// If historyOnlyKey is false and the value of key is saved in history, Put reports it as replaced and
+// the listener receives RemovalReplaced, whether the new value stays in history or is promoted to lru.
+// If historyOnlyKey is true the history saves no value, so nothing is replaced until the key is in lru.
 func (l *LowLRUK) Put(key, value interface{}) (delkey, delval interface{}, deleted bool) {
 	_, exists := l.lru.Get(key)
 	if exists {
@@ -86,10 +199,22 @@ func (l *LowLRUK) Put(key, value interface{}) (delkey, delval interface{}, delet
 		kv := v.(kValue)
 		kv.Count++
 		if kv.Count >= l.opts.k {
-			l.history.Delete(key)
+			l.replace(key, kv, func() {
+				l.promote(key)
+			})
 			delkey, delval, deleted = l.lru.Put(key, value)
-		} else {
+			if !l.opts.historyOnlyKey && !deleted {
+				delkey, delval, deleted = key, kv.Value, true
+			}
+		} else if l.opts.historyOnlyKey {
 			l.history.Put(key, kv)
+		} else {
+			delkey, delval, deleted = key, kv.Value, true
+			old := kv
+			kv.Value = value
+			l.replace(key, old, func() {
+				l.history.Put(key, kv)
+			})
 		}
 	} else {
 		kv := kValue{
@@ -101,6 +226,9 @@ func (l *LowLRUK) Put(key, value interface{}) (delkey, delval interface{}, delet
 		} else {
 			kv.Value = value
 			delkey, delval, deleted = l.history.Put(key, kv)
+			if deleted {
+				delval = delval.(kValue).Value
+			}
 		}
 	}
 	return
@@ -127,7 +255,7 @@ func (l *LowLRUK) Get(key interface{}) (value interface{}, exists bool) {
 			exists = true
 			kv.Count++
 			if kv.Count >= l.opts.k {
-				l.history.Delete(key)
+				l.promote(key)
 				l.lru.Put(key, kv.Value)
 			} else {
 				l.history.Put(key, kv)
@@ -142,6 +270,68 @@ func (l *LowLRUK) Get(key interface{}) (value interface{}, exists bool) {
 	return
 }
 
+// Peek return cache value without updating the history or the lru
+func (l *LowLRUK) Peek(key interface{}) (value interface{}, exists bool) {
+	value, exists = peek(l.lru, key)
+	if exists || l.history == nil || l.opts.historyOnlyKey {
+		return
+	}
+	v, exists := peek(l.history, key)
+	if exists {
+		value = v.(kValue).Value
+	}
+	return
+}
+
+// GetEntry return the value and metadata of key without updating the history or the lru.
+// Segment is history for the values saved in the history, Count is the number of accesses recorded by the history.
+//
+// lru and history that do not implement EntryInspector report only the key and value.
+func (l *LowLRUK) GetEntry(key interface{}) (info EntryInfo, exists bool) {
+	info, exists = getEntry(l.lru, key)
+	if exists || l.history == nil || l.opts.historyOnlyKey {
+		return
+	}
+	info, exists = getEntry(l.history, key)
+	if exists {
+		kv := info.Value.(kValue)
+		info.Value = kv.Value
+		info.Count = kv.Count
+		info.Segment = `history`
+	}
+	return
+}
+
+// Pin exempts key from eviction, a value saved in the history is moved to the lru and pinned there.
+// Returns ErrNotPinnable if the lru does not implement Pinner.
+func (l *LowLRUK) Pin(key interface{}) error {
+	p, ok := l.lru.(Pinner)
+	if !ok {
+		return ErrNotPinnable
+	}
+	if l.history != nil && !l.opts.historyOnlyKey && !contains(l.lru, key) {
+		if v, exists := peek(l.history, key); exists {
+			l.promote(key)
+			l.lru.Put(key, v.(kValue).Value)
+		}
+	}
+	return p.Pin(key)
+}
+
+// Unpin makes key evictable again, returns false if it is not pinned
+func (l *LowLRUK) Unpin(key interface{}) (unpinned bool) {
+	if p, ok := l.lru.(Pinner); ok {
+		unpinned = p.Unpin(key)
+	}
+	return
+}
+
+// Contains reports whether the key is cached without updating the history or the lru
+func (l *LowLRUK) Contains(key interface{}) (exists bool) {
+	_, exists = l.Peek(key)
+	return
+}
+
 // Delete key from cache
 func (l *LowLRUK) Delete(key ...interface{}) (changed int) {
 	changed = l.lru.Delete(key...)
@@ -171,3 +361,59 @@ func (l *LowLRUK) Clear() {
 		l.history.Clear()
 	}
 }
+
+// Range calls f for each live entry of the lru, then for each value saved in the history if not historyOnlyKey
+func (l *LowLRUK) Range(f func(key, value interface{}) bool) {
+	next := true
+	rangeLow(l.lru, func(key, value interface{}) bool {
+		next = f(key, value)
+		return next
+	})
+	if !next || l.history == nil || l.opts.historyOnlyKey {
+		return
+	}
+	rangeLow(l.history, func(key, value interface{}) bool {
+		return f(key, value.(kValue).Value)
+	})
+}
+
+// RangeHistory calls f for each live entry of the history, count is the number of times the key has been accessed.
+// If historyOnlyKey is true value is always nil.
+func (l *LowLRUK) RangeHistory(f func(key, value interface{}, count int) bool) {
+	if l.history == nil {
+		return
+	}
+	rangeLow(l.history, func(key, value interface{}) bool {
+		kv := value.(kValue)
+		return f(key, kv.Value, kv.Count)
+	})
+}
+
+func (l *LowLRUK) rangeKeyed(f func(k, key, value interface{}) bool) {
+	next := true
+	l.lru.(keyedCache).rangeKeyed(func(k, key, value interface{}) bool {
+		next = f(k, key, value)
+		return next
+	})
+	if !next || l.history == nil || l.opts.historyOnlyKey {
+		return
+	}
+	l.history.(keyedCache).rangeKeyed(func(k, key, value interface{}) bool {
+		return f(k, key, value.(kValue).Value)
+	})
+}
+func (l *LowLRUK) pinnedKeyed(k interface{}) bool {
+	// only the lru pins
+	return l.lru.(keyedCache).pinnedKeyed(k)
+}
+func (l *LowLRUK) deleteKeyed(k ...interface{}) (changed int) {
+	changed = l.lru.(keyedCache).deleteKeyed(k...)
+	if l.history != nil {
+		if l.opts.historyOnlyKey {
+			l.history.(keyedCache).deleteKeyed(k...)
+		} else {
+			changed += l.history.(keyedCache).deleteKeyed(k...)
+		}
+	}
+	return
+}
@@ -1,22 +1,26 @@
 package gcache
 
+import "fmt"
+
 var defaultLowLRUKOptions = lowLRUKOptions{
 	k:              2,
 	historyOnlyKey: true,
 }
 
 type lowLRUKOptions struct {
+	optionErrors
 	k              int
 	historyOnlyKey bool
+	onRemoval      RemovalListener
 }
 type LowLRUKOption interface {
-	apply(*lowLRUKOptions)
+	applyLowLRUK(*lowLRUKOptions)
 }
 type funcLowLRUKOption struct {
 	f func(*lowLRUKOptions)
 }
 
-func (fdo *funcLowLRUKOption) apply(do *lowLRUKOptions) {
+func (fdo *funcLowLRUKOption) applyLowLRUK(do *lowLRUKOptions) {
 	fdo.f(do)
 }
 func newFuncLowLRUKOption(f func(*lowLRUKOptions)) *funcLowLRUKOption {
@@ -29,7 +33,8 @@ func newFuncLowLRUKOption(f func(*lowLRUKOptions)) *funcLowLRUKOption {
 func WithLowLRUK(k int) LowLRUKOption {
 	return newFuncLowLRUKOption(func(po *lowLRUKOptions) {
 		if k < 1 {
-			panic("lru-k k must > 0")
+			po.invalid(fmt.Sprintf(`lru-k k must > 0, got %d`, k))
+			return
 		}
 		po.k = k
 	})
@@ -1,33 +1,57 @@
 package gcache
 
-import (
-	"runtime"
-	"time"
-)
+import "runtime"
 
 type LRU struct {
 	*wrapper
 }
 
-func NewLRU(opt ...LRUOption) (lru *LRU) {
+// NewLRU create a lru, it panics if an option is invalid
+func NewLRU(opt ...LRUOption) *LRU {
+	lru, e := TryNewLRU(opt...)
+	if e != nil {
+		panic(e)
+	}
+	return lru
+}
+
+// TryNewLRU is NewLRU but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLRU(opt ...LRUOption) (lru *LRU, e error) {
 	opts := defaultLRUOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyLRU(&opts)
+	}
+	if e = opts.err(); e != nil {
+		return
+	}
+	opts.keyFunc = namespaceKeyFunc(opts.keyFunc)
+	if opts.clock == nil {
+		opts.clock = SystemClock
 	}
-	w := &wrapper{
-		impl: NewLowLRU(
+	w := newWrapper(
+		NewLowLRU(
 			WithLowLRUCapacity(opts.capacity),
 			WithLowLRUExpiry(opts.expiry),
+			WithLowLRUExpireAfterWrite(opts.expireAfterWrite),
+			WithLowLRUExpiryPolicy(opts.expiryPolicy),
+			WithLowLRUKeyFunc(opts.keyFunc),
+			WithLowLRUPinLimit(opts.pinLimit),
+			WithLowLRUClock(opts.clock),
 		),
-		closed: make(chan struct{}),
+		opts.keyFunc,
+		opts.prefixIndex,
+		opts.onRemoval,
+	)
+	if opts.mrcSamples > 0 {
+		w.mrc = newShards(opts.capacity, opts.mrcSamples)
 	}
 	lru = &LRU{
 		wrapper: w,
 	}
-	if opts.expiry > 0 {
-		ticker := time.NewTicker(opts.clear)
+	if opts.clear > 0 && newExpiration(opts.expiry, opts.expireAfterWrite, opts.expiryPolicy, opts.clock).enabled() {
+		ticker := opts.clock.NewTicker(opts.clear)
 		w.ticker = ticker
-		go w.clearExpired(ticker.C)
+		go w.clearExpired(ticker.Chan())
 		runtime.SetFinalizer(lru, (*LRU).stop)
 	}
 	return
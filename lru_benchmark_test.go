@@ -44,3 +44,15 @@ func BenchmarkLruNotFoundAdd(b *testing.B) {
 	go fn(0x9900)
 	finish.Wait()
 }
+
+func BenchmarkNamespaceQuota(b *testing.B) {
+	l := gcache.NewLRU(gcache.WithLRUCapacity(100000))
+	for i := 0; i < 90000; i++ {
+		l.Put(i, i)
+	}
+	ns := l.Namespace("ns", gcache.WithNamespaceQuota(1000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ns.Put(i, i)
+	}
+}
@@ -2,168 +2,347 @@ package gcache
 
 import (
 	"container/list"
-	"time"
 )
 
 type lrufifo struct {
 	keys     map[interface{}]*list.Element
 	hot      *list.List
-	expiry   time.Duration
+	expiry   expiration
 	capacity int
 	lru      bool
+	keyFunc  KeyFunc
+	pinned   int
+	pinLimit int
+
+	onRemoval keyedListener
 }
 
-func newLRUFIFO(lru bool, capacity int, expiry time.Duration) *lrufifo {
+func newLRUFIFO(lru bool, capacity int, expiry expiration, keyFunc KeyFunc, pinLimit int) *lrufifo {
 	return &lrufifo{
 		keys:     make(map[interface{}]*list.Element, capacity),
 		hot:      list.New(),
-		expiry:   expiry,
+		expiry:   expiry.withWheel(),
 		capacity: capacity,
 		lru:      lru,
+		keyFunc:  keyFunc,
+		pinLimit: pinLimit,
+	}
+}
+
+// SetRemovalListener replace the listener, nil removes it
+func (l *lrufifo) SetRemovalListener(listener RemovalListener) {
+	l.onRemoval = listener.keyed()
+}
+func (l *lrufifo) notify(v cacheValue, value interface{}, reason RemovalReason) {
+	if l.onRemoval != nil {
+		l.onRemoval(v.MapKey(), v.GetKey(), value, reason)
 	}
 }
 
 func (l *lrufifo) ClearExpired() {
-	if l.expiry > 0 {
-		var (
-			ele  *list.Element
-			v    cacheValue
-			hot  = l.hot
-			keys = l.keys
-		)
-		for {
-			ele = hot.Front()
-			if ele == nil {
-				break
-			}
-			v = ele.Value.(cacheValue)
-			if v.IsDeleted() {
-				hot.Remove(ele)
-				delete(keys, v.GetKey())
-			} else {
-				break
-			}
-		}
+	l.expiry.ClearExpired(l.expired)
+}
+func (l *lrufifo) expired(v cacheValue) {
+	k := v.MapKey()
+	if ele, exists := l.keys[k]; exists {
+		l.remove(k, ele, RemovalExpired)
 	}
 }
+func (l *lrufifo) remove(k interface{}, ele *list.Element, reason RemovalReason) cacheValue {
+	v := l.hot.Remove(ele).(cacheValue)
+	delete(l.keys, k)
+	l.expiry.Remove(v)
+	l.unpin(v)
+	l.notify(v, v.GetValue(), reason)
+	return v
+}
 
 // Add the value to the cache, only when the key does not exist
 func (l *lrufifo) Add(key, value interface{}) (added bool) {
-	ele, exists := l.keys[key]
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	now := l.expiry.Nano()
+	ele, exists := l.keys[k]
 	if exists {
 		v := ele.Value.(cacheValue)
-		if v.IsDeleted() {
+		if l.expiry.IsDeletedAt(v, now) {
 			added = true
+			l.unpin(v)
+			l.notify(v, v.GetValue(), RemovalExpired)
+			v.SetKey(key)
 			v.SetValue(value)
-			l.moveHot(ele)
+			l.moveHot(ele, expiryCreate, now)
 			l.ClearExpired()
 		}
 	} else {
 		added = true
-		l.add(key, value)
+		l.add(k, key, value, now)
 	}
 	return
 }
 
-func (l *lrufifo) add(key, value interface{}) (delkey, delval interface{}, deleted bool) {
+func (l *lrufifo) add(k, key, value interface{}, now int64) (delkey, delval interface{}, deleted bool) {
 	// capacity limit reached, pop front
 	if l.hot.Len() >= l.capacity {
 		deleted = true
 		ele := l.hot.Front()
 		v := ele.Value.(cacheValue)
+		// skip pinned, pinLimit leaves at least one entry for eviction
+		for v.Pinned() {
+			l.hot.MoveToBack(ele)
+			ele = l.hot.Front()
+			v = ele.Value.(cacheValue)
+		}
 		delkey = v.GetKey()
 		delval = v.GetValue()
-		delete(l.keys, delkey)
-		l.hot.Remove(ele)
+		l.remove(v.MapKey(), ele, RemovalEvicted)
 	}
 	// new value
-	v := newValue(key, value, l.expiry)
-	l.keys[key] = l.hot.PushBack(v)
+	v := newValue(k, key, value, l.expiry, now)
+	l.keys[k] = l.hot.PushBack(v)
 	return
 }
 
-func (l *lrufifo) moveHot(ele *list.Element) {
+func (l *lrufifo) moveHot(ele *list.Element, event expiryEvent, now int64) {
 	v := ele.Value.(cacheValue)
-	if l.expiry > 0 {
-		v.SetDeadline(time.Now().Add(l.expiry))
-	}
+	v.Touch(now, event == expiryCreate)
+	l.expiry.Apply(v, event, now)
 	l.hot.MoveToBack(ele)
 }
 
 func (l *lrufifo) Put(key, value interface{}) (delkey, delval interface{}, deleted bool) {
-	ele, exists := l.keys[key]
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	now := l.expiry.Nano()
+	ele, exists := l.keys[k]
 	if exists {
 		// put
 		v := ele.Value.(cacheValue)
-		if v.IsDeleted() {
+		if l.expiry.IsDeletedAt(v, now) {
+			l.unpin(v)
+			l.notify(v, v.GetValue(), RemovalExpired)
+			v.SetKey(key)
 			v.SetValue(value)
 			// move hot
-			l.moveHot(ele)
+			l.moveHot(ele, expiryCreate, now)
 
 			l.ClearExpired()
 		} else {
 			deleted = true
 			delkey = key
 			delval = v.GetValue()
+			l.notify(v, delval, RemovalReplaced)
 
 			v.SetValue(value)
 			// move hot
-			l.moveHot(ele)
+			l.moveHot(ele, expiryUpdate, now)
 		}
 
 	} else {
-		delkey, delval, deleted = l.add(key, value)
+		delkey, delval, deleted = l.add(k, key, value, now)
 	}
 	return
 }
 
 // Get return cache value
 func (l *lrufifo) Get(key interface{}) (value interface{}, exists bool) {
-	ele, exists := l.keys[key]
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	ele, exists := l.keys[k]
 	if !exists {
 		return
 	}
+	now := l.expiry.Nano()
 	v := ele.Value.(cacheValue)
-	if v.IsDeleted() {
-		delete(l.keys, key)
-		l.hot.Remove(ele)
+	if l.expiry.IsDeletedAt(v, now) {
+		l.remove(k, ele, RemovalExpired)
 		exists = false
 		l.ClearExpired()
 		return
 	}
 	value = v.GetValue()
 
-	// fifo not need move hot
+	// fifo not need move hot, the read still refreshes the deadline
+	if l.lru {
+		l.moveHot(ele, expiryRead, now)
+	} else {
+		v.Touch(now, false)
+		l.expiry.Apply(v, expiryRead, now)
+	}
+	return
+}
+
+// Peek return cache value without moving it
+func (l *lrufifo) Peek(key interface{}) (value interface{}, exists bool) {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	ele, exists := l.keys[k]
+	if !exists {
+		return
+	}
+	v := ele.Value.(cacheValue)
+	if l.expiry.IsDeleted(v) {
+		exists = false
+		return
+	}
+	value = v.GetValue()
+	return
+}
+
+// GetEntry return the value and metadata of key without moving it
+func (l *lrufifo) GetEntry(key interface{}) (info EntryInfo, exists bool) {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	ele, exists := l.keys[k]
+	if !exists {
+		return
+	}
+	v := ele.Value.(cacheValue)
+	if l.expiry.IsDeleted(v) {
+		exists = false
+		return
+	}
 	if l.lru {
-		l.moveHot(ele)
+		info = newEntryInfo(v, `lru`, l.expiry.Nano())
+	} else {
+		info = newEntryInfo(v, `fifo`, l.expiry.Nano())
+	}
+	return
+}
+
+// Pin exempts key from eviction, it is still removed by Delete, Clear and expiry
+func (l *lrufifo) Pin(key interface{}) error {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return CheckKey(k)
+	}
+	ele, exists := l.keys[k]
+	if !exists {
+		return ErrNotCached
+	}
+	v := ele.Value.(cacheValue)
+	if l.expiry.IsDeleted(v) {
+		return ErrNotCached
+	} else if v.Pinned() {
+		return nil
+	} else if l.pinned >= l.pinLimit {
+		return errPinLimit(l.pinLimit, l.capacity)
 	}
+	v.SetPinned(true)
+	l.pinned++
+	return nil
+}
+
+// Unpin makes key evictable again, returns false if it is not pinned
+func (l *lrufifo) Unpin(key interface{}) (unpinned bool) {
+	k, ok := mapKey(l.keyFunc, key)
+	if !ok {
+		return
+	}
+	ele, exists := l.keys[k]
+	if exists {
+		unpinned = l.unpin(ele.Value.(cacheValue))
+	}
+	return
+}
+func (l *lrufifo) unpin(v cacheValue) bool {
+	if !v.Pinned() {
+		return false
+	}
+	v.SetPinned(false)
+	l.pinned--
+	return true
+}
+
+// Contains reports whether the key is cached without moving it
+func (l *lrufifo) Contains(key interface{}) (exists bool) {
+	_, exists = l.Peek(key)
 	return
 }
 
 func (l *lrufifo) Delete(key ...interface{}) (changed int) {
-	var (
-		ele    *list.Element
-		exists bool
-	)
-	for _, k := range key {
-		ele, exists = l.keys[k]
-		if exists {
-			changed++
-			delete(l.keys, k)
-			l.hot.Remove(ele)
+	for _, key := range key {
+		if k, ok := mapKey(l.keyFunc, key); ok {
+			changed += l.delete(k)
 		}
 	}
 	return
 }
+func (l *lrufifo) deleteKeyed(k ...interface{}) (changed int) {
+	for _, k := range k {
+		changed += l.delete(k)
+	}
+	return
+}
+func (l *lrufifo) delete(k interface{}) int {
+	ele, exists := l.keys[k]
+	if !exists {
+		return 0
+	}
+	l.remove(k, ele, RemovalDeleted)
+	return 1
+}
 
 func (l *lrufifo) Len() int {
 	return l.hot.Len()
 }
 
 func (l *lrufifo) Clear() {
+	if l.onRemoval != nil {
+		var v cacheValue
+		for ele := l.hot.Front(); ele != nil; ele = ele.Next() {
+			v = ele.Value.(cacheValue)
+			l.onRemoval(v.MapKey(), v.GetKey(), v.GetValue(), RemovalDeleted)
+		}
+	}
 	l.hot.Init()
+	l.expiry.Clear()
+	l.pinned = 0
 	for k := range l.keys {
 		delete(l.keys, k)
 	}
 	return
 }
+
+// Range calls f for each live entry from front to back, the front is the next to be evicted
+func (l *lrufifo) Range(f func(key, value interface{}) bool) {
+	l.rangeKeyed(func(k, key, value interface{}) bool {
+		return f(key, value)
+	})
+}
+func (l *lrufifo) rangeKeyed(f func(k, key, value interface{}) bool) {
+	var (
+		v    cacheValue
+		next *list.Element
+	)
+	for ele := l.hot.Front(); ele != nil; ele = next {
+		next = ele.Next()
+		v = ele.Value.(cacheValue)
+		if l.expiry.IsDeleted(v) {
+			continue
+		}
+		if !f(v.MapKey(), v.GetKey(), v.GetValue()) {
+			break
+		}
+	}
+}
+func (l *lrufifo) keyed() bool {
+	return true
+}
+func (l *lrufifo) pinnedKeyed(k interface{}) bool {
+	ele, exists := l.keys[k]
+	return exists && ele.Value.(cacheValue).Pinned()
+}
+func (l *lrufifo) setKeyedListener(listener keyedListener) {
+	l.onRemoval = listener
+}
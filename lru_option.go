@@ -1,26 +1,29 @@
 package gcache
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var defaultLRUOptions = lruOptions{
-	expiry:   0,
-	capacity: 1000,
-	clear:    time.Minute * 10,
+	commonOptions: commonOptions{
+		capacity: 1000,
+		pinLimit: defaultPinLimit,
+		clear:    time.Minute * 10,
+	},
 }
 
 type lruOptions struct {
-	expiry   time.Duration
-	capacity int
-	clear    time.Duration
+	commonOptions
 }
 type LRUOption interface {
-	apply(*lruOptions)
+	applyLRU(*lruOptions)
 }
 type funcLRUOption struct {
 	f func(*lruOptions)
 }
 
-func (fdo *funcLRUOption) apply(do *lruOptions) {
+func (fdo *funcLRUOption) applyLRU(do *lruOptions) {
 	fdo.f(do)
 }
 func newFuncLRUOption(f func(*lruOptions)) *funcLRUOption {
@@ -29,18 +32,35 @@ func newFuncLRUOption(f func(*lruOptions)) *funcLRUOption {
 	}
 }
 
-// WithLRUExpiry if <=0, it will not expire due to time
+// WithLRUExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity
 func WithLRUExpiry(expiry time.Duration) LRUOption {
 	return newFuncLRUOption(func(o *lruOptions) {
 		o.expiry = expiry
 	})
 }
 
+// WithLRUExpireAfterWrite expire after write, the deadline is refreshed only by write no matter how often it is read.
+// if <=0, it will not expire due to age. If both are set the entry expires at the earlier deadline.
+func WithLRUExpireAfterWrite(expiry time.Duration) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithLRUExpiryPolicy computes a variable time to live for every entry, it overrides WithLRUExpiry.
+// WithLRUExpireAfterWrite is still applied as a hard limit.
+func WithLRUExpiryPolicy(policy ExpiryPolicy) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
 // WithLRUCapacity set the maximum amount of data to be cached
 func WithLRUCapacity(capacity int) LRUOption {
 	return newFuncLRUOption(func(o *lruOptions) {
 		if capacity < 1 {
-			panic(`lru capacity must > 0`)
+			o.invalid(fmt.Sprintf(`lru capacity must > 0, got %d`, capacity))
+			return
 		}
 		o.capacity = capacity
 	})
@@ -52,3 +72,52 @@ func WithLRUClear(duration time.Duration) LRUOption {
 		po.clear = duration
 	})
 }
+
+// WithLRUKeyFunc normalises keys before they reach the internal map, eg. BytesKey StringsKey
+func WithLRUKeyFunc(f KeyFunc) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithLRUPrefixIndex index string keys in a radix tree, so DeletePrefix does not walk the whole cache
+func WithLRUPrefixIndex(enable bool) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		o.prefixIndex = enable
+	})
+}
+
+// WithLRUPinLimit set the fraction of capacity that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithLRUPinLimit(fraction float64) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithLRUOnRemoval receives every evicted, expired, deleted or replaced entry, it is called while the cache is locked and must not call any method of the cache.
+//
+// A value referenced by a Handle is passed when its last Handle is released.
+func WithLRUOnRemoval(listener RemovalListener) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		o.onRemoval = listener
+	})
+}
+
+// WithLRUClock set the source of time and of the sweeper ticker, nil is SystemClock
+func WithLRUClock(clock Clock) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		o.clock = clock
+	})
+}
+
+// WithLRUMissRatioCurve enable MissRatioCurve, it samples at most samples keys of the reads, 0 disables it, it must not be negative.
+// The memory used is proportional to samples, eg. 8192 is usually accurate enough.
+func WithLRUMissRatioCurve(samples int) LRUOption {
+	return newFuncLRUOption(func(o *lruOptions) {
+		if checkMissRatioSamples(&o.optionErrors, samples) {
+			o.mrcSamples = samples
+		}
+	})
+}
@@ -5,12 +5,13 @@ import (
 	"time"
 
 	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestLRU(t *testing.T) {
 	// hot
-	var l gcache.Cache
+	var l fullCache
 	l = gcache.NewLRU(
 		gcache.WithLRUCapacity(3),
 	)
@@ -74,9 +75,11 @@ func TestLRU(t *testing.T) {
 	// expire
 	count = 3
 	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Now())
 	l = gcache.NewLRU(
 		gcache.WithLRUCapacity(count),
 		gcache.WithLRUExpiry(duration),
+		gcache.WithLRUClock(clock),
 	)
 	for i := 0; i < count; i++ {
 		l.Put(i, i)
@@ -89,7 +92,7 @@ func TestLRU(t *testing.T) {
 		assert.True(t, exists)
 		assert.Equal(t, key, val)
 	}
-	time.Sleep(duration)
+	clock.Advance(duration)
 	size := l.Len()
 	assert.Equal(t, size, count)
 	for i := 0; i < count; i++ {
@@ -100,21 +103,23 @@ func TestLRU(t *testing.T) {
 	}
 
 	// clear timer
+	clock = gcachetest.NewFakeClock(time.Now())
 	l = gcache.NewLRU(
 		gcache.WithLRUCapacity(count+1),
 		gcache.WithLRUExpiry(duration),
 		gcache.WithLRUClear(duration),
+		gcache.WithLRUClock(clock),
 	)
 	for i := 0; i < count; i++ {
 		l.Put(i, i)
 		size := l.Len()
 		assert.Equal(t, size, i+1)
 	}
-	time.Sleep(duration / 2)
+	clock.Advance(duration / 2)
 	l.Put("ok", "value")
 	size = l.Len()
 	assert.Equal(t, size, count+1)
-	time.Sleep(duration/2 + duration/3)
+	clock.Advance(duration/2 + duration/3)
 
 	size = l.Len()
 	assert.Equal(t, size, 1)
@@ -124,7 +129,7 @@ func TestLRU(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, val, "value")
 
-	time.Sleep(duration / 2)
+	clock.Advance(duration / 2)
 	size = l.Len()
 	assert.Equal(t, size, 1)
 	key = "ok"
@@ -132,7 +137,7 @@ func TestLRU(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, val, "value")
 
-	time.Sleep(duration * 2)
+	clock.Advance(duration * 2)
 	size = l.Len()
 	assert.Equal(t, size, 0)
 
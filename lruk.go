@@ -1,24 +1,43 @@
 package gcache
 
-import (
-	"runtime"
-	"time"
-)
+import "runtime"
 
 type LRUK struct {
 	*wrapper
 }
 
-func NewLRUK(opt ...LRUKOption) (lruk *LRUK) {
+// NewLRUK create a lruk, it panics if an option is invalid
+func NewLRUK(opt ...LRUKOption) *LRUK {
+	lruk, e := TryNewLRUK(opt...)
+	if e != nil {
+		panic(e)
+	}
+	return lruk
+}
+
+// TryNewLRUK is NewLRUK but returns an *OptionError listing every invalid option instead of panicking
+func TryNewLRUK(opt ...LRUKOption) (lruk *LRUK, e error) {
 	opts := defaultLRUKOptions
 	for _, o := range opt {
-		o.apply(&opts)
+		o.applyLRUK(&opts)
+	}
+	if e = opts.err(); e != nil {
+		return
+	}
+	opts.keyFunc = namespaceKeyFunc(opts.keyFunc)
+	if opts.clock == nil {
+		opts.clock = SystemClock
 	}
 	// create default lru
 	if opts.lru == nil {
 		opts.lru = NewLowLRU(
 			WithLowLRUCapacity(opts.capacity),
 			WithLowLRUExpiry(opts.expiry),
+			WithLowLRUExpireAfterWrite(opts.expireAfterWrite),
+			WithLowLRUExpiryPolicy(opts.expiryPolicy),
+			WithLowLRUKeyFunc(opts.keyFunc),
+			WithLowLRUPinLimit(opts.pinLimit),
+			WithLowLRUClock(opts.clock),
 		)
 	}
 	// create default history
@@ -30,24 +49,33 @@ func NewLRUK(opt ...LRUKOption) (lruk *LRUK) {
 		opts.history = NewLowLRU(
 			WithLowLRUCapacity(capacity),
 			WithLowLRUExpiry(opts.expiry),
+			WithLowLRUExpireAfterWrite(opts.expireAfterWrite),
+			WithLowLRUExpiryPolicy(newHistoryExpiry(opts.expiryPolicy)),
+			WithLowLRUKeyFunc(opts.keyFunc),
+			WithLowLRUClock(opts.clock),
 		)
 	}
 
-	w := &wrapper{
-		impl: NewLowLRUK(
+	w := newWrapper(
+		NewLowLRUK(
 			opts.history, opts.lru,
 			WithLowLRUK(opts.k),
 			WithLowLRUKHistoryOnlyKey(opts.historyOnlyKey),
 		),
-		closed: make(chan struct{}),
+		opts.keyFunc,
+		opts.prefixIndex,
+		opts.onRemoval,
+	)
+	if opts.mrcSamples > 0 {
+		w.mrc = newShards(opts.capacity, opts.mrcSamples)
 	}
 	lruk = &LRUK{
 		wrapper: w,
 	}
-	if opts.expiry > 0 {
-		ticker := time.NewTicker(opts.clear)
+	if opts.clear > 0 && newExpiration(opts.expiry, opts.expireAfterWrite, opts.expiryPolicy, opts.clock).enabled() {
+		ticker := opts.clock.NewTicker(opts.clear)
 		lruk.ticker = ticker
-		go w.clearExpired(ticker.C)
+		go w.clearExpired(ticker.Chan())
 		runtime.SetFinalizer(lruk, (*LRUK).stop)
 	}
 	return
@@ -55,3 +83,12 @@ func NewLRUK(opt ...LRUKOption) (lruk *LRUK) {
 func (l *LRUK) stop() {
 	l.wrapper.stop()
 }
+
+// RangeHistory calls f for each live entry of the history, count is the number of times the key has been accessed.
+//
+// RangeHistory holds the cache lock for the whole walk, f must not call any method of the cache.
+func (l *LRUK) RangeHistory(f func(key, value interface{}, count int) bool) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.impl.(*LowLRUK).RangeHistory(f)
+}
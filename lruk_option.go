@@ -1,31 +1,34 @@
 package gcache
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 var defaultLRUKOptions = lrukOptions{
+	commonOptions: commonOptions{
+		capacity: 1000,
+		pinLimit: defaultPinLimit,
+		clear:    time.Minute * 10,
+	},
 	historyOnlyKey: true,
-	expiry:         0,
-	capacity:       1000,
-	clear:          time.Minute * 10,
 	k:              2,
 }
 
 type lrukOptions struct {
+	commonOptions
 	lru, history   LowCache
 	historyOnlyKey bool
-	expiry         time.Duration
-	capacity       int
-	clear          time.Duration
 	k              int
 }
 type LRUKOption interface {
-	apply(*lrukOptions)
+	applyLRUK(*lrukOptions)
 }
 type funcLRUKOption struct {
 	f func(*lrukOptions)
 }
 
-func (fdo *funcLRUKOption) apply(do *lrukOptions) {
+func (fdo *funcLRUKOption) applyLRUK(do *lrukOptions) {
 	fdo.f(do)
 }
 func newFuncLRUKOption(f func(*lrukOptions)) *funcLRUKOption {
@@ -34,18 +37,35 @@ func newFuncLRUKOption(f func(*lrukOptions)) *funcLRUKOption {
 	}
 }
 
-// WithLRUKExpiry if <=0, it will not expire due to time
+// WithLRUKExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity
 func WithLRUKExpiry(expiry time.Duration) LRUKOption {
 	return newFuncLRUKOption(func(o *lrukOptions) {
 		o.expiry = expiry
 	})
 }
 
+// WithLRUKExpireAfterWrite expire after write, the deadline is refreshed only by write no matter how often it is read.
+// if <=0, it will not expire due to age. If both are set the entry expires at the earlier deadline.
+func WithLRUKExpireAfterWrite(expiry time.Duration) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithLRUKExpiryPolicy computes a variable time to live for every entry, it overrides WithLRUKExpiry.
+// WithLRUKExpireAfterWrite is still applied as a hard limit.
+func WithLRUKExpiryPolicy(policy ExpiryPolicy) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
 // WithLRUKCapacity set the maximum amount of data to be cached
 func WithLRUKCapacity(capacity int) LRUKOption {
 	return newFuncLRUKOption(func(o *lrukOptions) {
 		if capacity < 1 {
-			panic(`lru capacity must > 0`)
+			o.invalid(fmt.Sprintf(`lru capacity must > 0, got %d`, capacity))
+			return
 		}
 		o.capacity = capacity
 	})
@@ -62,7 +82,8 @@ func WithLRUKClear(duration time.Duration) LRUKOption {
 func WithLRUK(k int) LRUKOption {
 	return newFuncLRUKOption(func(po *lrukOptions) {
 		if k < 1 {
-			panic("lru-k k must > 0")
+			po.invalid(fmt.Sprintf(`lru-k k must > 0, got %d`, k))
+			return
 		}
 		po.k = k
 	})
@@ -88,3 +109,52 @@ func WithLRUKHistoryOnlyKey(onlyKey bool) LRUKOption {
 		po.historyOnlyKey = onlyKey
 	})
 }
+
+// WithLRUKKeyFunc normalises keys before they reach the internal map, eg. BytesKey StringsKey, only used by auto created lru and history
+func WithLRUKKeyFunc(f KeyFunc) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithLRUKPrefixIndex index string keys in a radix tree, so DeletePrefix does not walk the whole cache
+func WithLRUKPrefixIndex(enable bool) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		o.prefixIndex = enable
+	})
+}
+
+// WithLRUKPinLimit set the fraction of capacity of the lru created by default that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithLRUKPinLimit(fraction float64) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithLRUKOnRemoval receives every evicted, expired, deleted or replaced entry, it is called while the cache is locked and must not call any method of the cache.
+//
+// A value referenced by a Handle is passed when its last Handle is released.
+func WithLRUKOnRemoval(listener RemovalListener) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		o.onRemoval = listener
+	})
+}
+
+// WithLRUKClock set the source of time and of the sweeper ticker, it is passed to the lru and history created by default, nil is SystemClock
+func WithLRUKClock(clock Clock) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		o.clock = clock
+	})
+}
+
+// WithLRUKMissRatioCurve enable MissRatioCurve, it samples at most samples keys of the reads, 0 disables it, it must not be negative.
+// The memory used is proportional to samples, eg. 8192 is usually accurate enough.
+func WithLRUKMissRatioCurve(samples int) LRUKOption {
+	return newFuncLRUKOption(func(o *lrukOptions) {
+		if checkMissRatioSamples(&o.optionErrors, samples) {
+			o.mrcSamples = samples
+		}
+	})
+}
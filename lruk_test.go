@@ -5,15 +5,16 @@ import (
 	"time"
 
 	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestLRU_K3(t *testing.T) {
 	var (
-		l gcache.Cache
-		h gcache.LowCache
+		l fullCache
+		h fullLowCache
 	)
-	h = gcache.NewLowLRU(gcache.WithLowLRUCapacity(3))
+	h = full(gcache.NewLowLRU(gcache.WithLowLRUCapacity(3)))
 	// history value
 	l = gcache.NewLRUK(
 		gcache.WithLRUK(3),
@@ -61,10 +62,10 @@ func TestLRU_K3(t *testing.T) {
 }
 func TestLRU_K2(t *testing.T) {
 	var (
-		l gcache.Cache
-		h gcache.LowCache
+		l fullCache
+		h fullLowCache
 	)
-	h = gcache.NewLowLRU(gcache.WithLowLRUCapacity(3))
+	h = full(gcache.NewLowLRU(gcache.WithLowLRUCapacity(3)))
 	lru := gcache.NewLowLRU(
 		gcache.WithLowLRUCapacity(3),
 	)
@@ -154,7 +155,7 @@ func TestLRU_K2(t *testing.T) {
 
 func TestLRU_K1(t *testing.T) {
 	// hot
-	var l gcache.Cache
+	var l fullCache
 	l = gcache.NewLRUK(
 		gcache.WithLRUK(1),
 		gcache.WithLRUKCapacity(3),
@@ -219,10 +220,12 @@ func TestLRU_K1(t *testing.T) {
 	// expire
 	count = 3
 	duration := time.Millisecond * 10 * 5
+	clock := gcachetest.NewFakeClock(time.Now())
 	l = gcache.NewLRUK(
 		gcache.WithLRUK(1),
 		gcache.WithLRUKCapacity(count),
 		gcache.WithLRUKExpiry(duration),
+		gcache.WithLRUKClock(clock),
 	)
 	for i := 0; i < count; i++ {
 		l.Put(i, i)
@@ -235,7 +238,7 @@ func TestLRU_K1(t *testing.T) {
 		assert.True(t, exists)
 		assert.Equal(t, key, val)
 	}
-	time.Sleep(duration)
+	clock.Advance(duration)
 	size := l.Len()
 	assert.Equal(t, size, count)
 	for i := 0; i < count; i++ {
@@ -246,22 +249,24 @@ func TestLRU_K1(t *testing.T) {
 	}
 
 	// clear timer
+	clock = gcachetest.NewFakeClock(time.Now())
 	l = gcache.NewLRUK(
 		gcache.WithLRUK(1),
 		gcache.WithLRUKCapacity(count+1),
 		gcache.WithLRUKExpiry(duration),
 		gcache.WithLRUKClear(duration),
+		gcache.WithLRUKClock(clock),
 	)
 	for i := 0; i < count; i++ {
 		l.Put(i, i)
 		size := l.Len()
 		assert.Equal(t, size, i+1)
 	}
-	time.Sleep(duration / 2)
+	clock.Advance(duration / 2)
 	l.Put("ok", "value")
 	size = l.Len()
 	assert.Equal(t, size, count+1)
-	time.Sleep(duration/2 + duration/3)
+	clock.Advance(duration/2 + duration/3)
 
 	size = l.Len()
 	assert.Equal(t, size, 1)
@@ -271,7 +276,7 @@ func TestLRU_K1(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, val, "value")
 
-	time.Sleep(duration / 2)
+	clock.Advance(duration / 2)
 	size = l.Len()
 	assert.Equal(t, size, 1)
 	key = "ok"
@@ -279,7 +284,7 @@ func TestLRU_K1(t *testing.T) {
 	assert.True(t, exists)
 	assert.Equal(t, val, "value")
 
-	time.Sleep(duration * 2)
+	clock.Advance(duration * 2)
 	size = l.Len()
 	assert.Equal(t, size, 0)
 
@@ -296,3 +301,80 @@ func TestLRU_K1(t *testing.T) {
 	assert.False(t, vals[2].Exists)
 	assert.Nil(t, vals[2].Value)
 }
+
+func TestLowLRUKHistoryValue(t *testing.T) {
+	var removed []gcache.RemovalReason
+	l := gcache.NewLowLRUK(
+		gcache.NewLowLRU(gcache.WithLowLRUCapacity(10)),
+		gcache.NewLowLRU(gcache.WithLowLRUCapacity(10)),
+		gcache.WithLowLRUK(3),
+		gcache.WithLowLRUKHistoryOnlyKey(false),
+	)
+	l.SetRemovalListener(func(key, value interface{}, reason gcache.RemovalReason) {
+		assert.Equal(t, 1, key)
+		removed = append(removed, reason)
+	})
+
+	// the value saved in history exists, Add does not count it
+	assert.True(t, l.Add(1, 1))
+	assert.False(t, l.Add(1, 2))
+	v, exists := l.Peek(1)
+	assert.True(t, exists)
+	assert.Equal(t, 1, v)
+
+	// Put replaces the value in history
+	delkey, delval, deleted := l.Put(1, 2)
+	assert.True(t, deleted)
+	assert.Equal(t, 1, delkey)
+	assert.Equal(t, 1, delval)
+	assert.Equal(t, []gcache.RemovalReason{gcache.RemovalReplaced}, removed)
+	l.RangeHistory(func(key, value interface{}, count int) bool {
+		assert.Equal(t, 2, value)
+		assert.Equal(t, 2, count)
+		return true
+	})
+
+	// Put that promotes to lru replaces the value in history
+	delkey, delval, deleted = l.Put(1, 3)
+	assert.True(t, deleted)
+	assert.Equal(t, 1, delkey)
+	assert.Equal(t, 2, delval)
+	assert.Equal(t, []gcache.RemovalReason{gcache.RemovalReplaced, gcache.RemovalReplaced}, removed)
+	info, _ := l.GetEntry(1)
+	assert.Equal(t, "lru", info.Segment)
+	assert.Equal(t, 3, info.Value)
+	assert.Equal(t, 1, l.Len())
+	assert.NoError(t, gcache.CheckInvariants(l))
+}
+
+func TestLowLRUKHistoryOnlyKey(t *testing.T) {
+	var removed int
+	l := gcache.NewLowLRUK(
+		gcache.NewLowLRU(gcache.WithLowLRUCapacity(10)),
+		gcache.NewLowLRU(gcache.WithLowLRUCapacity(10)),
+		gcache.WithLowLRUK(3),
+	)
+	l.SetRemovalListener(func(key, value interface{}, reason gcache.RemovalReason) {
+		removed++
+	})
+
+	// the history only counts the accesses
+	assert.False(t, l.Add(1, 1))
+	_, _, deleted := l.Put(1, 2)
+	assert.False(t, deleted)
+	assert.False(t, l.Contains(1))
+	// the third access adds the value
+	assert.True(t, l.Add(1, 3))
+	v, exists := l.Peek(1)
+	assert.True(t, exists)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, 0, removed)
+
+	// the key in lru is replaced
+	delkey, delval, deleted := l.Put(1, 4)
+	assert.True(t, deleted)
+	assert.Equal(t, 1, delkey)
+	assert.Equal(t, 3, delval)
+	assert.Equal(t, 1, removed)
+	assert.NoError(t, gcache.CheckInvariants(l))
+}
@@ -0,0 +1,528 @@
+package gcache_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+)
+
+const (
+	modelCapacity = 8
+	modelKeys     = 12
+)
+
+type modelEntry struct {
+	key, value int
+	// count the frequency of lfu or the accesses recorded by the history of lru-k
+	count int
+	// tick the order of eviction of lru and fifo
+	tick   int
+	pinned bool
+}
+
+// model is a trivially correct lru, fifo or lfu without expiry
+type model struct {
+	policy   string
+	capacity int
+	pinLimit int
+	entries  map[int]*modelEntry
+	tick     int
+}
+
+func newModel(policy string, capacity int) *model {
+	return &model{
+		policy:   policy,
+		capacity: capacity,
+		pinLimit: capacity / 2,
+		entries:  make(map[int]*modelEntry),
+	}
+}
+func (m *model) touch(e *modelEntry) {
+	m.tick++
+	e.tick = m.tick
+}
+func (m *model) access(e *modelEntry, write bool) {
+	switch m.policy {
+	case `lru`:
+		m.touch(e)
+	case `fifo`:
+		if write {
+			m.touch(e)
+		}
+	case `lfu`:
+		e.count++
+	}
+}
+
+// victims returns the keys that may be evicted, pinned entries skipped by lru and fifo are moved to the back
+func (m *model) victims() (keys []int) {
+	if m.policy == `lfu` {
+		min := 0
+		for _, e := range m.entries {
+			if e.pinned {
+				continue
+			}
+			if len(keys) == 0 || e.count < min {
+				min = e.count
+				keys = []int{e.key}
+			} else if e.count == min {
+				keys = append(keys, e.key)
+			}
+		}
+		return
+	}
+	for {
+		oldest := m.sorted()[0]
+		if !oldest.pinned {
+			return []int{oldest.key}
+		}
+		m.touch(oldest)
+	}
+}
+
+// sorted returns the entries in eviction order of lru and fifo
+func (m *model) sorted() []*modelEntry {
+	entries := make([]*modelEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].tick < entries[j].tick
+	})
+	return entries
+}
+
+// insert a new entry, pick chooses the evicted key from the victims
+func (m *model) insert(key, value int, pick func(victims []int) int) (delkey, delval interface{}, deleted bool) {
+	if len(m.entries) >= m.capacity {
+		e := m.entries[pick(m.victims())]
+		delete(m.entries, e.key)
+		delkey, delval, deleted = e.key, e.value, true
+	}
+	e := &modelEntry{
+		key:   key,
+		value: value,
+		count: 1,
+	}
+	m.touch(e)
+	m.entries[key] = e
+	return
+}
+func (m *model) Add(key, value int, pick func([]int) int) bool {
+	if _, exists := m.entries[key]; exists {
+		return false
+	}
+	m.insert(key, value, pick)
+	return true
+}
+func (m *model) Put(key, value int, pick func([]int) int) (delkey, delval interface{}, deleted bool) {
+	if e, exists := m.entries[key]; exists {
+		delkey, delval, deleted = key, e.value, true
+		e.value = value
+		m.access(e, true)
+		return
+	}
+	return m.insert(key, value, pick)
+}
+func (m *model) Get(key int) (value interface{}, exists bool) {
+	e, exists := m.entries[key]
+	if exists {
+		value = e.value
+		m.access(e, false)
+	}
+	return
+}
+func (m *model) Peek(key int) (value interface{}, exists bool) {
+	e, exists := m.entries[key]
+	if exists {
+		value = e.value
+	}
+	return
+}
+func (m *model) Delete(key int) int {
+	if _, exists := m.entries[key]; exists {
+		delete(m.entries, key)
+		return 1
+	}
+	return 0
+}
+func (m *model) Pin(key int) error {
+	e, exists := m.entries[key]
+	if !exists {
+		return gcache.ErrNotCached
+	} else if e.pinned {
+		return nil
+	}
+	pinned := 0
+	for _, e := range m.entries {
+		if e.pinned {
+			pinned++
+		}
+	}
+	if pinned >= m.pinLimit {
+		return gcache.ErrPinLimit
+	}
+	e.pinned = true
+	return nil
+}
+func (m *model) Unpin(key int) bool {
+	e, exists := m.entries[key]
+	if !exists || !e.pinned {
+		return false
+	}
+	e.pinned = false
+	return true
+}
+func (m *model) Len() int {
+	return len(m.entries)
+}
+func (m *model) Clear() {
+	m.entries = make(map[int]*modelEntry)
+}
+
+// lrukModel is LowLRUK composed of two lru models, the history saves values
+type lrukModel struct {
+	k            int
+	history, lru *model
+}
+
+func newLRUKModel(k, capacity int) *lrukModel {
+	return &lrukModel{
+		k:       k,
+		history: newModel(`lru`, capacity/2),
+		lru:     newModel(`lru`, capacity/2),
+	}
+}
+func (m *lrukModel) Add(key, value int, pick func([]int) int) bool {
+	if _, exists := m.lru.Get(key); exists {
+		return false
+	} else if _, exists = m.history.Get(key); exists {
+		return false
+	}
+	m.history.insert(key, value, pick)
+	return true
+}
+func (m *lrukModel) Put(key, value int, pick func([]int) int) (delkey, delval interface{}, deleted bool) {
+	if _, exists := m.lru.Get(key); exists {
+		return m.lru.Put(key, value, pick)
+	}
+	e, exists := m.history.entries[key]
+	if !exists {
+		return m.history.insert(key, value, pick)
+	}
+	m.history.touch(e)
+	e.count++
+	if e.count >= m.k {
+		m.history.Delete(key)
+		delkey, delval, deleted = m.lru.Put(key, value, pick)
+		if !deleted {
+			delkey, delval, deleted = key, e.value, true
+		}
+		return
+	}
+	delkey, delval, deleted = key, e.value, true
+	e.value = value
+	return
+}
+func (m *lrukModel) Get(key int, pick func([]int) int) (value interface{}, exists bool) {
+	if value, exists = m.lru.Get(key); exists {
+		return
+	}
+	e, exists := m.history.entries[key]
+	if !exists {
+		return
+	}
+	value = e.value
+	m.history.touch(e)
+	e.count++
+	if e.count >= m.k {
+		m.history.Delete(key)
+		m.lru.Put(key, e.value, pick)
+	}
+	return
+}
+func (m *lrukModel) Peek(key int) (value interface{}, exists bool) {
+	if value, exists = m.lru.Peek(key); !exists {
+		value, exists = m.history.Peek(key)
+	}
+	return
+}
+func (m *lrukModel) Delete(key int) int {
+	return m.lru.Delete(key) + m.history.Delete(key)
+}
+func (m *lrukModel) Len() int {
+	return m.lru.Len() + m.history.Len()
+}
+func (m *lrukModel) Clear() {
+	m.lru.Clear()
+	m.history.Clear()
+}
+
+// modelCase creates an algorithm and its model
+type modelCase struct {
+	name  string
+	cache func() fullLowCache
+	model func() *model
+	lruk  func() *lrukModel
+}
+
+var modelCases = []modelCase{
+	{
+		name: `lru`,
+		cache: func() fullLowCache {
+			return full(gcache.NewLowLRU(gcache.WithLowLRUCapacity(modelCapacity)))
+		},
+		model: func() *model { return newModel(`lru`, modelCapacity) },
+	},
+	{
+		name: `fifo`,
+		cache: func() fullLowCache {
+			return full(gcache.NewLowFIFO(gcache.WithLowFIFOCapacity(modelCapacity)))
+		},
+		model: func() *model { return newModel(`fifo`, modelCapacity) },
+	},
+	{
+		name: `lfu`,
+		cache: func() fullLowCache {
+			return full(gcache.NewLowLFU(gcache.WithLowLFUCapacity(modelCapacity)))
+		},
+		model: func() *model { return newModel(`lfu`, modelCapacity) },
+	},
+	{
+		name: `lruk`,
+		cache: func() fullLowCache {
+			return gcache.NewLowLRUK(
+				gcache.NewLowLRU(gcache.WithLowLRUCapacity(modelCapacity/2)),
+				gcache.NewLowLRU(gcache.WithLowLRUCapacity(modelCapacity/2)),
+				gcache.WithLowLRUK(3),
+				gcache.WithLowLRUKHistoryOnlyKey(false),
+			)
+		},
+		lruk: func() *lrukModel { return newLRUKModel(3, modelCapacity) },
+	},
+}
+
+// runModel applies the operations encoded by ops to the algorithm and its model,
+// it returns an error at the first step whose results or invariants differ.
+func runModel(mc modelCase, ops []byte) (e error) {
+	c := mc.cache()
+	var (
+		m    *model
+		k    *lrukModel
+		step int
+		op   string
+	)
+	if mc.lruk != nil {
+		k = mc.lruk()
+	} else {
+		m = mc.model()
+	}
+	// picked the key evicted by the algorithm, nil if the operation does not report it
+	var picked interface{}
+	pick := func(victims []int) int {
+		if picked == nil {
+			return victims[0]
+		}
+		for _, key := range victims {
+			if key == picked {
+				return key
+			}
+		}
+		if e == nil {
+			e = fmt.Errorf(`evicted %v, want one of %v`, picked, victims)
+		}
+		return victims[0]
+	}
+	expect := func(format string, actual, want interface{}) {
+		if e == nil && actual != want {
+			e = fmt.Errorf(format+` = %v, want %v`, actual, want)
+		}
+	}
+	for i := 0; i+1 < len(ops) && e == nil; i += 2 {
+		step = i / 2
+		key := int(ops[i+1]) % modelKeys
+		value := step
+		switch ops[i] % 16 {
+		case 0, 1, 2, 3:
+			op = fmt.Sprint(`Put(`, key, `)`)
+			delkey, delval, deleted := c.Put(key, value)
+			picked = delkey
+			var wkey, wval interface{}
+			var wdeleted bool
+			if k != nil {
+				wkey, wval, wdeleted = k.Put(key, value, pick)
+			} else {
+				wkey, wval, wdeleted = m.Put(key, value, pick)
+			}
+			expect(`deleted`, deleted, wdeleted)
+			expect(`delkey`, delkey, wkey)
+			expect(`delval`, delval, wval)
+		case 4, 5, 6:
+			op = fmt.Sprint(`Get(`, key, `)`)
+			v, exists := c.Get(key)
+			// only the lru of lru-k evicts, its victim is determined
+			picked = nil
+			var wv interface{}
+			var wexists bool
+			if k != nil {
+				wv, wexists = k.Get(key, pick)
+			} else {
+				wv, wexists = m.Get(key)
+			}
+			expect(`exists`, exists, wexists)
+			expect(`value`, v, wv)
+		case 7:
+			op = fmt.Sprint(`Add(`, key, `)`)
+			before := c.Len()
+			added := c.Add(key, value)
+			// Add does not report the evicted key, it is the one no longer cached
+			picked = nil
+			if added && before == c.Len() {
+				picked = evicted(c, m, k)
+			}
+			var wadded bool
+			if k != nil {
+				wadded = k.Add(key, value, pick)
+			} else {
+				wadded = m.Add(key, value, pick)
+			}
+			expect(`added`, added, wadded)
+		case 8, 9:
+			op = fmt.Sprint(`Peek(`, key, `)`)
+			v, exists := c.Peek(key)
+			var wv interface{}
+			var wexists bool
+			if k != nil {
+				wv, wexists = k.Peek(key)
+			} else {
+				wv, wexists = m.Peek(key)
+			}
+			expect(`exists`, exists, wexists)
+			expect(`value`, v, wv)
+			expect(`Contains`, c.Contains(key), wexists)
+		case 10:
+			op = fmt.Sprint(`Delete(`, key, `)`)
+			changed := c.Delete(key)
+			if k != nil {
+				expect(`changed`, changed, k.Delete(key))
+			} else {
+				expect(`changed`, changed, m.Delete(key))
+			}
+		case 11, 12:
+			if k != nil {
+				continue
+			}
+			p := c.(gcache.Pinner)
+			if ops[i]%16 == 11 {
+				op = fmt.Sprint(`Pin(`, key, `)`)
+				err := p.Pin(key)
+				werr := m.Pin(key)
+				if (err == nil) != (werr == nil) || (werr != nil && !errors.Is(err, werr)) {
+					expect(`error`, err, werr)
+				}
+			} else {
+				op = fmt.Sprint(`Unpin(`, key, `)`)
+				expect(`unpinned`, p.Unpin(key), m.Unpin(key))
+			}
+		case 13, 14:
+			op = `Range`
+			e = checkRange(c, m, k)
+		case 15:
+			if ops[i+1]%8 != 0 {
+				continue
+			}
+			op = `Clear`
+			c.Clear()
+			if k != nil {
+				k.Clear()
+			} else {
+				m.Clear()
+			}
+		}
+		if e == nil {
+			if k != nil {
+				expect(`Len()`, c.Len(), k.Len())
+			} else {
+				expect(`Len()`, c.Len(), m.Len())
+			}
+		}
+		if e == nil {
+			e = gcache.CheckInvariants(c)
+		}
+	}
+	if e != nil {
+		e = fmt.Errorf(`step %d %s: %w`, step, op, e)
+	}
+	return
+}
+
+// evicted returns the key cached by the model but not by the algorithm
+func evicted(c fullLowCache, m *model, k *lrukModel) interface{} {
+	entries := make(map[int]*modelEntry)
+	if k != nil {
+		for key, e := range k.lru.entries {
+			entries[key] = e
+		}
+		for key, e := range k.history.entries {
+			entries[key] = e
+		}
+	} else {
+		entries = m.entries
+	}
+	for key := range entries {
+		if !c.Contains(key) {
+			return key
+		}
+	}
+	return nil
+}
+
+// checkRange compares the entries visited by Range, lru and fifo are also compared in eviction order
+func checkRange(c fullLowCache, m *model, k *lrukModel) error {
+	var keys []interface{}
+	c.Range(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	var want []*modelEntry
+	if k != nil {
+		want = append(k.lru.sorted(), k.history.sorted()...)
+	} else {
+		want = m.sorted()
+	}
+	if len(keys) != len(want) {
+		return fmt.Errorf(`Range visited %v, want %d entries`, keys, len(want))
+	}
+	if m != nil && m.policy == `lfu` {
+		for _, key := range keys {
+			if _, exists := m.entries[key.(int)]; !exists {
+				return fmt.Errorf(`Range visited %v which is not cached`, key)
+			}
+		}
+		return nil
+	}
+	for i, e := range want {
+		if keys[i] != e.key {
+			return fmt.Errorf(`Range visited %v, want %v at %d`, keys, e.key, i)
+		}
+	}
+	return nil
+}
+
+func TestModel(t *testing.T) {
+	ops := make([]byte, 1000)
+	for _, mc := range modelCases {
+		t.Run(mc.name, func(t *testing.T) {
+			for seed := int64(0); seed < 200; seed++ {
+				rand.New(rand.NewSource(seed)).Read(ops)
+				if e := runModel(mc, ops); e != nil {
+					t.Fatalf(`seed %d: %v`, seed, e)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package gcache
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+)
+
+// MissRatioEstimator is implemented by Cache that estimate their miss ratio curve, all Cache provided by gcache implement it.
+type MissRatioEstimator interface {
+	// MissRatioCurve returns the estimated hit ratios of the reads at other capacities, nil unless enabled
+	MissRatioCurve() []MissRatioPoint
+}
+
+// MissRatioPoint the estimated hit ratio of the cache at a capacity
+type MissRatioPoint struct {
+	Capacity int
+	HitRatio float64
+}
+
+const (
+	// shardsModulus the keys are sampled if their hash modulo shardsModulus is less than the threshold
+	shardsModulus = 1 << 24
+	// mrcPoints the number of points of the curve
+	mrcPoints = 64
+	// mrcRange the curve covers the capacities up to mrcRange times the capacity of the cache
+	mrcRange = 4
+)
+
+// shards estimates the lru miss ratio curve of the reads with fixed-size SHARDS.
+//
+// Only the keys whose hash is less than threshold are sampled, their reuse distance scaled by the sampling rate
+// is counted in histogram. When there are more than maxSamples keys the threshold is lowered,
+// so the memory is bounded by maxSamples.
+type shards struct {
+	threshold  uint64
+	maxSamples int
+	keys       map[interface{}]*shardsSample
+	// slots the sample last read at each time, nil if it was read again since.
+	// tree counts the samples of slots, the reuse distance of a key is the count after its time.
+	slots []*shardsSample
+	tree  fenwick
+	// now the time of the next read
+	now int
+	// heap the samples, the largest hash first
+	heap shardsHeap
+	// histogram bucket i counts the reuse distances in [i*width, (i+1)*width)
+	width     int
+	histogram []float64
+	// total the sampled reads, scaled like histogram when the threshold is lowered
+	total float64
+}
+type shardsSample struct {
+	key  interface{}
+	hash uint64
+	// time of the last read
+	time int
+}
+
+// checkMissRatioSamples reports samples < 0 to errs, 0 disables MissRatioCurve
+func checkMissRatioSamples(errs *optionErrors, samples int) (valid bool) {
+	if samples < 0 {
+		errs.invalid(fmt.Sprintf(`miss ratio curve samples must >= 0, got %d`, samples))
+		return false
+	}
+	return true
+}
+
+func newShards(capacity, maxSamples int) *shards {
+	width := capacity * mrcRange / mrcPoints
+	if width < 1 {
+		width = 1
+	}
+	return &shards{
+		threshold:  shardsModulus,
+		maxSamples: maxSamples,
+		keys:       make(map[interface{}]*shardsSample),
+		slots:      make([]*shardsSample, 64),
+		tree:       make(fenwick, 64),
+		width:      width,
+		histogram:  make([]float64, mrcPoints),
+	}
+}
+
+// Read records a read of the normalised key
+func (s *shards) Read(key interface{}) {
+	hash := hashKey(key) % shardsModulus
+	if hash >= s.threshold {
+		return
+	}
+	s.total++
+	sample, exists := s.keys[key]
+	if !exists {
+		sample = &shardsSample{
+			key:  key,
+			hash: hash,
+		}
+		s.keys[key] = sample
+		heap.Push(&s.heap, sample)
+		s.touch(sample)
+		if len(s.keys) > s.maxSamples {
+			s.lower()
+		}
+		return
+	}
+	// the samples read after the previous read of key
+	distance := len(s.keys) - s.tree.sum(sample.time+1)
+	bucket := int(float64(distance)*shardsModulus/float64(s.threshold)) / s.width
+	if bucket < len(s.histogram) {
+		s.histogram[bucket]++
+	}
+	s.slots[sample.time] = nil
+	s.tree.add(sample.time, -1)
+	s.touch(sample)
+}
+
+// touch sets the time of sample to now
+func (s *shards) touch(sample *shardsSample) {
+	if s.now == len(s.slots) {
+		s.compact()
+	}
+	sample.time = s.now
+	s.slots[s.now] = sample
+	s.tree.add(s.now, 1)
+	s.now++
+}
+
+// compact renumbers the times of the samples from 0 in order, the slots are doubled if more than half are used
+func (s *shards) compact() {
+	slots := s.slots
+	if len(s.keys)*2 > len(slots) {
+		slots = make([]*shardsSample, len(slots)*2)
+		s.tree = make(fenwick, len(slots))
+	}
+	n := 0
+	for _, sample := range s.slots[:s.now] {
+		if sample != nil {
+			sample.time = n
+			slots[n] = sample
+			n++
+		}
+	}
+	for i := n; i < len(slots); i++ {
+		slots[i] = nil
+	}
+	s.slots = slots
+	s.now = n
+	s.tree.reset(n)
+}
+
+// lower the threshold to the largest hash sampled and remove its keys
+func (s *shards) lower() {
+	max := s.heap[0].hash
+	for len(s.heap) != 0 && s.heap[0].hash == max {
+		sample := heap.Pop(&s.heap).(*shardsSample)
+		delete(s.keys, sample.key)
+		s.slots[sample.time] = nil
+		s.tree.add(sample.time, -1)
+	}
+	// the counts are scaled to the new sampling rate
+	scale := float64(max) / float64(s.threshold)
+	for i := range s.histogram {
+		s.histogram[i] *= scale
+	}
+	s.total *= scale
+	s.threshold = max
+}
+
+// shardsHeap a max-heap of the samples by hash
+type shardsHeap []*shardsSample
+
+func (h shardsHeap) Len() int            { return len(h) }
+func (h shardsHeap) Less(i, j int) bool  { return h[i].hash > h[j].hash }
+func (h shardsHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *shardsHeap) Push(x interface{}) { *h = append(*h, x.(*shardsSample)) }
+func (h *shardsHeap) Pop() interface{} {
+	old := *h
+	n := len(old) - 1
+	x := old[n]
+	old[n] = nil
+	*h = old[:n]
+	return x
+}
+
+// fenwick a binary indexed tree of counts, the indexes start from 0
+type fenwick []int
+
+func (f fenwick) add(i, delta int) {
+	for i++; i <= len(f); i += i & -i {
+		f[i-1] += delta
+	}
+}
+
+// sum returns the total count of [0, i)
+func (f fenwick) sum(i int) (total int) {
+	for ; i > 0; i -= i & -i {
+		total += f[i-1]
+	}
+	return
+}
+
+// reset sets the counts of [0, n) to 1 and the others to 0
+func (f fenwick) reset(n int) {
+	for i := range f {
+		if i < n {
+			f[i] = 1
+		} else {
+			f[i] = 0
+		}
+	}
+	for i := 1; i <= len(f); i++ {
+		if j := i + i&-i; j <= len(f) {
+			f[j-1] += f[i-1]
+		}
+	}
+}
+
+// Curve returns the estimated hit ratios, the capacities are increasing
+func (s *shards) Curve() []MissRatioPoint {
+	points := make([]MissRatioPoint, len(s.histogram))
+	hits := 0.0
+	for i, count := range s.histogram {
+		hits += count
+		points[i].Capacity = (i + 1) * s.width
+		if s.total > 0 {
+			points[i].HitRatio = hits / s.total
+		}
+	}
+	return points
+}
+
+// hashKey returns a hash of the normalised key that does not depend on the process
+func hashKey(key interface{}) uint64 {
+	var x uint64
+	switch k := key.(type) {
+	case string:
+		return hashString(k)
+	case int:
+		x = uint64(k)
+	case int64:
+		x = uint64(k)
+	case int32:
+		x = uint64(k)
+	case uint:
+		x = uint64(k)
+	case uint64:
+		x = k
+	case uint32:
+		x = uint64(k)
+	case NamespacedKey:
+		return hashString(k.Namespace) ^ hashKey(k.Key)*0x9e3779b97f4a7c15
+	default:
+		return hashString(fmt.Sprintf(`%T:%v`, key, key))
+	}
+	// splitmix64 finalizer
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// MissRatioCurve returns the estimated hit ratios of the reads at other capacities,
+// nil unless enabled by WithXXXMissRatioCurve.
+//
+// The curve is estimated for lru, it approximates the other algorithms.
+func (w *wrapper) MissRatioCurve() (points []MissRatioPoint) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.mrc != nil {
+		points = w.mrc.Curve()
+	}
+	return
+}
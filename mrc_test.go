@@ -0,0 +1,116 @@
+package gcache_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+// hitRatio returns the hit ratio of the last point whose capacity is at most capacity
+func hitRatio(points []gcache.MissRatioPoint, capacity int) (ratio float64) {
+	for _, p := range points {
+		if p.Capacity > capacity {
+			break
+		}
+		ratio = p.HitRatio
+	}
+	return
+}
+
+func TestMissRatioCurve(t *testing.T) {
+	assert.Nil(t, gcache.NewLRU().MissRatioCurve())
+
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(100), gcache.WithLRUMissRatioCurve(1000)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(100), gcache.WithFIFOMissRatioCurve(1000)),
+		gcache.NewLFU(gcache.WithLFUCapacity(100), gcache.WithLFUMissRatioCurve(1000)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(100), gcache.WithLRUKMissRatioCurve(1000)),
+	}
+	for _, c := range caches {
+		// a loop over 200 keys only hits with a capacity of 200
+		for pass := 0; pass < 10; pass++ {
+			for i := 0; i < 200; i++ {
+				if _, exists := c.Get(i); !exists {
+					c.Put(i, i)
+				}
+			}
+		}
+		points := c.MissRatioCurve()
+		assert.Equal(t, 64, len(points))
+		// the curve covers 4 times the capacity
+		assert.Equal(t, 384, points[len(points)-1].Capacity)
+		assert.Equal(t, 0.0, hitRatio(points, 100))
+		assert.Equal(t, 0.0, hitRatio(points, 199))
+		assert.InDelta(t, 0.9, hitRatio(points, 210), 0.01)
+		assert.InDelta(t, 0.9, hitRatio(points, 400), 0.01)
+
+		// namespaces share the curve
+		c.Namespace(`a`).Get(1)
+		assert.Equal(t, 64, len(c.Namespace(`a`).(fullCache).MissRatioCurve()))
+	}
+
+	// sampled, a loop over 1000 keys with only 100 keys sampled
+	c := gcache.NewLRU(gcache.WithLRUCapacity(500), gcache.WithLRUMissRatioCurve(100))
+	hits := 0
+	for pass := 0; pass < 10; pass++ {
+		for i := 0; i < 1000; i++ {
+			if _, exists := c.Get(i); exists {
+				hits++
+			} else {
+				c.Put(i, i)
+			}
+		}
+	}
+	points := c.MissRatioCurve()
+	assert.Equal(t, 0, hits)
+	assert.Equal(t, 0.0, hitRatio(points, 500))
+	assert.True(t, hitRatio(points, 800) < 0.2)
+	assert.True(t, hitRatio(points, 1300) > 0.8)
+}
+
+func TestMissRatioCurveExact(t *testing.T) {
+	// every key is sampled, the curve is the exact lru hit ratio
+	c := gcache.NewLRU(gcache.WithLRUCapacity(64), gcache.WithLRUMissRatioCurve(1000))
+	rand := rand.New(rand.NewSource(1))
+	var (
+		stack     []int
+		distances []int
+	)
+	for i := 0; i < 10000; i++ {
+		key := rand.Intn(200)
+		c.Get(key)
+		found := -1
+		for j := len(stack) - 1; j >= 0; j-- {
+			if stack[j] == key {
+				found = j
+				break
+			}
+		}
+		if found >= 0 {
+			distances = append(distances, len(stack)-1-found)
+			stack = append(stack[:found], stack[found+1:]...)
+		}
+		stack = append(stack, key)
+	}
+	for _, p := range c.MissRatioCurve() {
+		hits := 0
+		for _, d := range distances {
+			if d < p.Capacity {
+				hits++
+			}
+		}
+		assert.InDelta(t, float64(hits)/10000, p.HitRatio, 1e-9, p.Capacity)
+	}
+}
+
+func BenchmarkMissRatioCurve(b *testing.B) {
+	c := gcache.NewLRU(gcache.WithLRUCapacity(10000), gcache.WithLRUMissRatioCurve(10000))
+	for i := 0; i < b.N; i++ {
+		key := i * 7919 % 100000
+		if _, exists := c.Get(key); !exists {
+			c.Put(key, i)
+		}
+	}
+}
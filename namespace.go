@@ -0,0 +1,445 @@
+package gcache
+
+import (
+	"container/list"
+	"strings"
+	"sync/atomic"
+)
+
+// NamespacedKey is the key saved in the shared LowCache for the key of a namespace.
+//
+// Range of the parent cache returns it for the entries of namespaces.
+type NamespacedKey struct {
+	Namespace string
+	Key       interface{}
+}
+
+// namespaceKeyFunc applies f to the key inside NamespacedKey
+func namespaceKeyFunc(f KeyFunc) KeyFunc {
+	if f == nil {
+		return nil
+	}
+	return func(key interface{}) interface{} {
+		if nk, ok := key.(NamespacedKey); ok {
+			nk.Key = f(nk.Key)
+			return nk
+		}
+		return f(key)
+	}
+}
+
+// Namespacer is implemented by Cache that share their capacity with namespaces, all Cache provided by gcache implement it.
+//
+// The Cache returned by Namespace implements the same optional interfaces as the cache.
+type Namespacer interface {
+	// Namespace returns a view of the cache whose keys never collide with other namespaces, all namespaces share the capacity.
+	// name must not contain "/", it separates the names of nested namespaces.
+	Namespace(name string, opt ...NamespaceOption) Cache
+	// NamespaceStats returns the counters of the namespace
+	NamespaceStats(name string) NamespaceStats
+}
+
+// NamespaceStats counters of a namespace
+type NamespaceStats struct {
+	// Len the number of cached data, expired entries are counted until they are cleared
+	Len int
+	// Quota the maximum amount of data of the namespace, 0 is unlimited
+	Quota int
+	// Hits Get, BatchGet and GetOrSet that found the key
+	Hits uint64
+	// Misses Get, BatchGet and GetOrSet that did not find the key
+	Misses uint64
+	// Evictions entries evicted by the capacity limit or the quota
+	Evictions int
+}
+
+// namespace state, guarded by the wrapper lock except hits and misses
+type namespace struct {
+	hits   uint64
+	misses uint64
+
+	quota     int
+	evictions int
+	// entries the storedKey of the cached data, least recently put or read first, the quota evicts from the front
+	entries  *list.List
+	elements map[interface{}]*list.Element
+}
+
+// namespace returns the state of name, it is created if not exists
+func (w *wrapper) namespace(name string) *namespace {
+	ns := w.namespaces[name]
+	if ns == nil {
+		ns = &namespace{
+			entries:  list.New(),
+			elements: make(map[interface{}]*list.Element),
+		}
+		w.namespaces[name] = ns
+	}
+	return ns
+}
+
+// len returns the number of cached data, expired entries are counted until they are cleared
+func (ns *namespace) len() int {
+	return len(ns.elements)
+}
+
+// put adds the key or moves it to the back
+func (ns *namespace) put(k, key interface{}) {
+	if ele := ns.elements[k]; ele != nil {
+		ele.Value = storedKey{k, key}
+		ns.entries.MoveToBack(ele)
+	} else {
+		ns.elements[k] = ns.entries.PushBack(storedKey{k, key})
+	}
+}
+
+// read moves the key to the back if it is cached
+func (ns *namespace) read(k interface{}) {
+	if ele := ns.elements[k]; ele != nil {
+		ns.entries.MoveToBack(ele)
+	}
+}
+
+// remove returns false if the key is not cached
+func (ns *namespace) remove(k interface{}) bool {
+	ele := ns.elements[k]
+	if ele == nil {
+		return false
+	}
+	ns.entries.Remove(ele)
+	delete(ns.elements, k)
+	return true
+}
+
+// keys returns the storedKey of the cached data
+func (ns *namespace) keys() []storedKey {
+	keys := make([]storedKey, 0, ns.entries.Len())
+	for ele := ns.entries.Front(); ele != nil; ele = ele.Next() {
+		keys = append(keys, ele.Value.(storedKey))
+	}
+	return keys
+}
+
+func (ns *namespace) clear() {
+	ns.entries.Init()
+	ns.elements = make(map[interface{}]*list.Element)
+}
+
+// Namespace returns a view of the cache, its keys are saved as NamespacedKey so they never collide with other namespaces.
+//
+// All namespaces share the capacity of the cache, WithNamespaceQuota stops one namespace from evicting the others.
+// The options replace the settings of the namespace, call without options to keep them.
+// The namespace of a namespace is named parent/name and is independent of the parent,
+// name must not contain "/" so it never collides with a nested namespace, Namespace panics otherwise.
+func (w *wrapper) Namespace(name string, opt ...NamespaceOption) Cache {
+	checkNamespace(name)
+	return w.namespaceCache(name, opt)
+}
+
+// checkNamespace panics if name contains the separator of nested namespaces
+func checkNamespace(name string) {
+	if strings.Contains(name, `/`) {
+		panic(`gcache: namespace name contains "/": ` + name)
+	}
+}
+
+// namespaceCache returns the view of the namespace whose full name is name
+func (w *wrapper) namespaceCache(name string, opt []NamespaceOption) Cache {
+	w.m.Lock()
+	defer w.m.Unlock()
+	ns := w.namespace(name)
+	if len(opt) != 0 {
+		opts := defaultNamespaceOptions
+		for _, o := range opt {
+			o.apply(&opts)
+		}
+		ns.quota = opts.quota
+	}
+	return &namespaceCache{
+		w:    w,
+		ns:   ns,
+		name: name,
+	}
+}
+
+// NamespaceStats returns the counters of the namespace
+func (w *wrapper) NamespaceStats(name string) (stats NamespaceStats) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if ns := w.namespaces[name]; ns != nil {
+		stats = NamespaceStats{
+			Len:       ns.len(),
+			Quota:     ns.quota,
+			Hits:      atomic.LoadUint64(&ns.hits),
+			Misses:    atomic.LoadUint64(&ns.misses),
+			Evictions: ns.evictions,
+		}
+	}
+	return
+}
+
+// namespaceCache is the Cache view of a namespace
+type namespaceCache struct {
+	w    *wrapper
+	ns   *namespace
+	name string
+}
+
+func (n *namespaceCache) key(key interface{}) NamespacedKey {
+	return NamespacedKey{
+		Namespace: n.name,
+		Key:       key,
+	}
+}
+
+// unwrap returns the key of the namespace, ok is false if it belongs to another namespace
+func (n *namespaceCache) unwrap(key interface{}) (k interface{}, ok bool) {
+	nk, ok := key.(NamespacedKey)
+	if ok && nk.Namespace == n.name {
+		k = nk.Key
+	} else {
+		ok = false
+	}
+	return
+}
+func (n *namespaceCache) stat(exists bool) {
+	if exists {
+		atomic.AddUint64(&n.ns.hits, 1)
+	} else {
+		atomic.AddUint64(&n.ns.misses, 1)
+	}
+}
+
+// Add the value to the cache, only when the key does not exist
+func (n *namespaceCache) Add(key, value interface{}) (added bool) {
+	return n.w.Add(n.key(key), value)
+}
+
+// Put key value to cache
+func (n *namespaceCache) Put(key, value interface{}) {
+	n.w.Put(n.key(key), value)
+}
+
+// TryPut put key value to cache, returns an error wrapping ErrUnhashableKey if the key cannot be used as a map key
+func (n *namespaceCache) TryPut(key, value interface{}) error {
+	return n.w.TryPut(n.key(key), value)
+}
+
+// Get return cache value
+func (n *namespaceCache) Get(key interface{}) (value interface{}, exists bool) {
+	value, exists = n.w.Get(n.key(key))
+	n.stat(exists)
+	return
+}
+
+// Peek return cache value without updating recency, frequency, history or deadline
+func (n *namespaceCache) Peek(key interface{}) (value interface{}, exists bool) {
+	return n.w.Peek(n.key(key))
+}
+
+// GetEntry return the value and metadata of key without updating recency, frequency, history or deadline
+func (n *namespaceCache) GetEntry(key interface{}) (info EntryInfo, exists bool) {
+	info, exists = n.w.GetEntry(n.key(key))
+	if exists {
+		info.Key = key
+	}
+	return
+}
+
+// Contains reports whether the key is cached without updating recency, frequency, history or deadline
+func (n *namespaceCache) Contains(key interface{}) (exists bool) {
+	return n.w.Contains(n.key(key))
+}
+
+// BatchPut pairs to cache
+func (n *namespaceCache) BatchPut(pair ...interface{}) {
+	keys := make([]interface{}, len(pair))
+	copy(keys, pair)
+	for i := 0; i < len(keys); i += 2 {
+		keys[i] = n.key(keys[i])
+	}
+	n.w.BatchPut(keys...)
+}
+
+// BatchGet return cache values
+func (n *namespaceCache) BatchGet(key ...interface{}) (vals []Value) {
+	keys := make([]interface{}, len(key))
+	for i, k := range key {
+		keys[i] = n.key(k)
+	}
+	vals = n.w.BatchGet(keys...)
+	for _, v := range vals {
+		n.stat(v.Exists)
+	}
+	return
+}
+
+// Delete key from cache
+func (n *namespaceCache) Delete(key ...interface{}) (changed int) {
+	keys := make([]interface{}, len(key))
+	for i, k := range key {
+		keys[i] = n.key(k)
+	}
+	return n.w.Delete(keys...)
+}
+
+// Len returns the number of cached data of the namespace
+func (n *namespaceCache) Len() (count int) {
+	n.w.m.Lock()
+	defer n.w.m.Unlock()
+	count = n.ns.len()
+	return
+}
+
+// Clear all cached data of the namespace
+func (n *namespaceCache) Clear() {
+	n.w.m.Lock()
+	defer n.w.m.Unlock()
+	if keys := n.ns.keys(); len(keys) != 0 {
+		n.w.deleteStored(keys)
+	}
+}
+
+// PutWithTags put key value to cache and associate it with tags, the tags replace the previous ones of key.
+//
+// Tags are kept until the key is evicted, expired or deleted, Put without tags does not change them.
+func (n *namespaceCache) PutWithTags(key, value interface{}, tags ...interface{}) {
+	keys := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		keys[i] = n.key(tag)
+	}
+	n.w.PutWithTags(n.key(key), value, keys...)
+}
+
+// InvalidateTag deletes every key associated with tag, returns the number of deleted keys
+func (n *namespaceCache) InvalidateTag(tag interface{}) (changed int) {
+	return n.w.InvalidateTag(n.key(tag))
+}
+
+// DeleteFunc deletes every live entry for which f returns true, returns the number of deleted keys.
+//
+// DeleteFunc holds the cache lock for the whole walk, f must not call any method of the cache.
+func (n *namespaceCache) DeleteFunc(f func(key, value interface{}) bool) (changed int) {
+	return n.w.DeleteFunc(func(key, value interface{}) bool {
+		k, ok := n.unwrap(key)
+		return ok && f(k, value)
+	})
+}
+
+// DeletePrefix deletes every key starting with prefix, returns the number of deleted keys.
+// Only string keys, or keys normalised to string by KeyFunc, are matched.
+func (n *namespaceCache) DeletePrefix(prefix string) (changed int) {
+	n.w.m.Lock()
+	defer n.w.m.Unlock()
+	changed = n.w.deletePrefix(n.name, true, prefix)
+	return
+}
+
+// Pin exempts key from eviction, it is still removed by Delete, Clear and expiry.
+//
+// A pinned entry is not evicted by the quota of the namespace either.
+func (n *namespaceCache) Pin(key interface{}) error {
+	return n.w.Pin(n.key(key))
+}
+
+// Unpin makes key evictable again, returns false if it is not pinned
+func (n *namespaceCache) Unpin(key interface{}) (unpinned bool) {
+	return n.w.Unpin(n.key(key))
+}
+
+// PutPinned put key value to cache and pin it.
+// If an error is returned the value is still put but not pinned.
+func (n *namespaceCache) PutPinned(key, value interface{}) error {
+	return n.w.PutPinned(n.key(key), value)
+}
+
+// Acquire return a Handle of the cache value, it is counted as a Get.
+//
+// The value is not passed to the removal listener until every Handle of it is released.
+func (n *namespaceCache) Acquire(key interface{}) (h Handle, exists bool) {
+	h, exists = n.w.Acquire(n.key(key))
+	n.stat(exists)
+	if exists {
+		h = namespaceHandle{
+			Handle: h,
+			key:    key,
+		}
+	}
+	return
+}
+
+// Range calls f for each live entry of the namespace in eviction order, if f returns false stop the iteration.
+//
+// Range walks the whole cache and holds the cache lock, f must not call any method of the cache.
+func (n *namespaceCache) Range(f func(key, value interface{}) bool) {
+	n.w.Range(func(key, value interface{}) bool {
+		if k, ok := n.unwrap(key); ok {
+			return f(k, value)
+		}
+		return true
+	})
+}
+
+// Keys returns a snapshot of the live keys of the namespace in eviction order
+func (n *namespaceCache) Keys() (keys []interface{}) {
+	keys = make([]interface{}, 0)
+	n.Range(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return
+}
+
+// Values returns a snapshot of the live values of the namespace in eviction order
+func (n *namespaceCache) Values() (vals []interface{}) {
+	vals = make([]interface{}, 0)
+	n.Range(func(key, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return
+}
+
+// Compute calls f with the current value of the key under the cache lock.
+// If keep is true newValue is stored, otherwise the key is deleted.
+//
+// f must not call any method of the cache.
+func (n *namespaceCache) Compute(key interface{}, f func(oldValue interface{}, exists bool) (newValue interface{}, keep bool)) (value interface{}, exists bool) {
+	return n.w.Compute(n.key(key), f)
+}
+
+// GetOrSet returns the existing value for the key if present, otherwise it stores and returns the given value.
+// loaded is true if the value was loaded, false if stored.
+func (n *namespaceCache) GetOrSet(key, value interface{}) (actual interface{}, loaded bool) {
+	actual, loaded = n.w.GetOrSet(n.key(key), value)
+	n.stat(loaded)
+	return
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the cache is equal to old.
+// The old value must be of a comparable type.
+func (n *namespaceCache) CompareAndSwap(key, oldValue, newValue interface{}) (swapped bool) {
+	return n.w.CompareAndSwap(n.key(key), oldValue, newValue)
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// The old value must be of a comparable type.
+func (n *namespaceCache) CompareAndDelete(key, oldValue interface{}) (deleted bool) {
+	return n.w.CompareAndDelete(n.key(key), oldValue)
+}
+
+// Namespace returns the namespace parent/name of the shared cache, name must not contain "/"
+func (n *namespaceCache) Namespace(name string, opt ...NamespaceOption) Cache {
+	checkNamespace(name)
+	return n.w.namespaceCache(n.name+`/`+name, opt)
+}
+
+// NamespaceStats returns the counters of the namespace parent/name
+func (n *namespaceCache) NamespaceStats(name string) NamespaceStats {
+	return n.w.NamespaceStats(n.name + `/` + name)
+}
+
+// MissRatioCurve returns the estimated hit ratios of the reads of the shared cache
+func (n *namespaceCache) MissRatioCurve() []MissRatioPoint {
+	return n.w.MissRatioCurve()
+}
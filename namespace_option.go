@@ -0,0 +1,36 @@
+package gcache
+
+var defaultNamespaceOptions = namespaceOptions{
+	quota: 0,
+}
+
+type namespaceOptions struct {
+	quota int
+}
+type NamespaceOption interface {
+	apply(*namespaceOptions)
+}
+type funcNamespaceOption struct {
+	f func(*namespaceOptions)
+}
+
+func (fdo *funcNamespaceOption) apply(do *namespaceOptions) {
+	fdo.f(do)
+}
+func newFuncNamespaceOption(f func(*namespaceOptions)) *funcNamespaceOption {
+	return &funcNamespaceOption{
+		f: f,
+	}
+}
+
+// WithNamespaceQuota set the maximum amount of data of the namespace, when it is reached the namespace evicts its own entries,
+// the least recently put or read first.
+// if <=0, the namespace is only limited by the capacity of the cache.
+func WithNamespaceQuota(quota int) NamespaceOption {
+	return newFuncNamespaceOption(func(o *namespaceOptions) {
+		if quota < 0 {
+			quota = 0
+		}
+		o.quota = quota
+	})
+}
@@ -0,0 +1,162 @@
+package gcache_test
+
+import (
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(10)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(10)),
+		gcache.NewLFU(gcache.WithLFUCapacity(10)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(10), gcache.WithLRUK(1)),
+	}
+	for _, l := range caches {
+		a := l.Namespace("a").(fullCache)
+		b := l.Namespace("b").(fullCache)
+		a.Put(1, "a1")
+		b.Put(1, "b1")
+		l.Put(1, "1")
+		assert.Equal(t, 3, l.Len())
+		assert.Equal(t, 1, a.Len())
+		assert.Equal(t, 1, b.Len())
+
+		v, exists := a.Get(1)
+		assert.True(t, exists)
+		assert.Equal(t, "a1", v)
+		v, _ = b.Get(1)
+		assert.Equal(t, "b1", v)
+		v, _ = l.Get(1)
+		assert.Equal(t, "1", v)
+		_, exists = a.Get(2)
+		assert.False(t, exists)
+		assert.Equal(t, []interface{}{1}, a.Keys())
+		assert.True(t, l.Contains(gcache.NamespacedKey{Namespace: "a", Key: 1}))
+
+		// tags and prefixes do not cross namespaces
+		a.PutWithTags("x/1", 1, "t")
+		b.PutWithTags("x/1", 1, "t")
+		assert.Equal(t, 1, a.InvalidateTag("t"))
+		assert.True(t, b.Contains("x/1"))
+		assert.Equal(t, 1, b.DeletePrefix("x/"))
+
+		a.Clear()
+		assert.Equal(t, 0, a.Len())
+		assert.Equal(t, 1, b.Len())
+		assert.Equal(t, 2, l.Len())
+
+		stats := l.NamespaceStats("a")
+		assert.Equal(t, uint64(1), stats.Hits)
+		assert.Equal(t, uint64(1), stats.Misses)
+		assert.Equal(t, 0, stats.Len)
+
+		l.Clear()
+		assert.Equal(t, 0, a.Len())
+		assert.Equal(t, 0, b.Len())
+	}
+
+	// evicted by the shared capacity
+	l := gcache.NewLRU(gcache.WithLRUCapacity(10))
+	a := l.Namespace("a").(fullCache)
+	b := l.Namespace("b").(fullCache)
+	b.Put(1, 1)
+	for i := 0; i < 10; i++ {
+		a.Put(i, i)
+	}
+	assert.Equal(t, 10, a.Len())
+	assert.Equal(t, 0, b.Len())
+	assert.Equal(t, 1, l.NamespaceStats("b").Evictions)
+
+	// KeyFunc normalises the keys of namespaces
+	l = gcache.NewLRU(gcache.WithLRUKeyFunc(gcache.BytesKey), gcache.WithLRUPrefixIndex(true))
+	a = l.Namespace("a").(fullCache)
+	a.Put([]byte("k/1"), 1)
+	assert.True(t, a.Contains("k/1"))
+	assert.Equal(t, 1, a.Len())
+	assert.Equal(t, 0, l.DeletePrefix("k/"))
+	assert.Equal(t, 1, a.DeletePrefix("k/"))
+	assert.Equal(t, 0, a.Len())
+}
+
+func TestNamespaceNested(t *testing.T) {
+	l := gcache.NewLRU()
+	ab := l.Namespace("a").(fullCache).Namespace("b")
+	ab.Put(1, "a/b")
+	assert.Equal(t, 1, l.NamespaceStats("a/b").Len)
+	assert.Equal(t, 0, l.NamespaceStats("a").Len)
+
+	// "/" would collide with the nested namespace
+	assert.Panics(t, func() {
+		l.Namespace("a/b")
+	})
+	assert.Panics(t, func() {
+		l.Namespace("a").(fullCache).Namespace("b/c")
+	})
+	v, exists := ab.Get(1)
+	assert.True(t, exists)
+	assert.Equal(t, "a/b", v)
+}
+
+func TestNamespaceQuota(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(10)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(10)),
+		gcache.NewLFU(gcache.WithLFUCapacity(10)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(10), gcache.WithLRUK(1)),
+	}
+	for _, l := range caches {
+		a := l.Namespace("a", gcache.WithNamespaceQuota(3)).(fullCache)
+		b := l.Namespace("b").(fullCache)
+		for i := 0; i < 5; i++ {
+			b.Put(i, i)
+		}
+		for i := 0; i < 100; i++ {
+			a.Put(i, i)
+		}
+		assert.Equal(t, 3, a.Len())
+		assert.Equal(t, 5, b.Len())
+		assert.Equal(t, 97, l.NamespaceStats("a").Evictions)
+		assert.Equal(t, 3, l.Namespace("a").Len())
+
+		// options replace the quota
+		l.Namespace("a", gcache.WithNamespaceQuota(0))
+		for i := 0; i < 5; i++ {
+			a.Put(i+100, i)
+		}
+		assert.Equal(t, 10, a.Len()+b.Len())
+		assert.Equal(t, 0, l.NamespaceStats("a").Quota)
+	}
+}
+
+func TestNamespaceQuotaOrder(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(10)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(10)),
+		gcache.NewLFU(gcache.WithLFUCapacity(10)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(10), gcache.WithLRUK(1)),
+	}
+	for _, l := range caches {
+		a := l.Namespace("a", gcache.WithNamespaceQuota(3)).(fullCache)
+		for i := 0; i < 3; i++ {
+			a.Put(i, i)
+		}
+		// the least recently put or read entry is evicted
+		a.Get(0)
+		a.Put(3, 3)
+		assert.False(t, a.Contains(1))
+		// pinned entries are skipped
+		assert.NoError(t, a.Pin(2))
+		a.Put(4, 4)
+		assert.False(t, a.Contains(0))
+		assert.ElementsMatch(t, []interface{}{2, 3, 4}, a.Keys())
+		assert.Equal(t, 2, l.NamespaceStats("a").Evictions)
+
+		l.Put("k", 1)
+		a.Clear()
+		assert.Equal(t, 0, a.Len())
+		assert.Equal(t, 1, l.Len())
+	}
+}
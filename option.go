@@ -0,0 +1,251 @@
+package gcache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// commonOptions the settings shared by the algorithms, it is embedded by the options of each algorithm
+type commonOptions struct {
+	optionErrors
+	expiry           time.Duration
+	expireAfterWrite time.Duration
+	expiryPolicy     ExpiryPolicy
+	capacity         int
+	keyFunc          KeyFunc
+	pinLimit         float64
+	onRemoval        RemovalListener
+	clock            Clock
+	// Cache only
+	clear       time.Duration
+	prefixIndex bool
+	mrcSamples  int
+}
+
+// OptionError lists every invalid option passed to a constructor or every invalid field of the Config passed to New.
+// errors.Is(e, ErrInvalidConfig) reports true.
+type OptionError struct {
+	Messages []string
+}
+
+func (e *OptionError) Error() string {
+	return ErrInvalidConfig.Error() + `: ` + strings.Join(e.Messages, `; `)
+}
+
+// Is reports whether target is ErrInvalidConfig
+func (e *OptionError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// optionErrors collects the invalid options instead of panicking at the first one,
+// NewXXX panic with them and TryNewXXX return them.
+type optionErrors struct {
+	messages []string
+}
+
+func (o *optionErrors) invalid(message string) {
+	o.messages = append(o.messages, message)
+}
+
+// err returns an *OptionError, or nil if every option is valid
+func (o *optionErrors) err() error {
+	if len(o.messages) == 0 {
+		return nil
+	}
+	return &OptionError{
+		Messages: o.messages,
+	}
+}
+
+// Option is accepted by every constructor, it sets a setting shared by the algorithms.
+// A constructor reports the settings its algorithm does not have as invalid, eg. LowCache has no clear timer.
+// LowLRUK and LoadingCache take their settings from the caches they wrap, they report the others.
+//
+// The algorithm-specific options such as WithLRUK are layered on top of it.
+type Option struct {
+	f func(*commonOptions)
+}
+
+func newOption(f func(*commonOptions)) Option {
+	return Option{
+		f: f,
+	}
+}
+func (o Option) applyLRU(opts *lruOptions) {
+	o.f(&opts.commonOptions)
+}
+func (o Option) applyFIFO(opts *fifoOptions) {
+	o.f(&opts.commonOptions)
+}
+func (o Option) applyLFU(opts *lfuOptions) {
+	o.f(&opts.commonOptions)
+}
+func (o Option) applyLRUK(opts *lrukOptions) {
+	o.f(&opts.commonOptions)
+}
+func (o Option) applyLowLRU(opts *lowLRUOptions) {
+	o.applyLow(&opts.commonOptions, `LowLRU`)
+}
+func (o Option) applyLowFIFO(opts *lowFIFOOptions) {
+	o.applyLow(&opts.commonOptions, `LowFIFO`)
+}
+func (o Option) applyLowLFU(opts *lowLFUOptions) {
+	o.applyLow(&opts.commonOptions, `LowLFU`)
+}
+
+// applyLow a LowCache has no clear timer, prefix index or miss ratio curve, they belong to the Cache wrapping it
+func (o Option) applyLow(opts *commonOptions, name string) {
+	o.f(opts)
+	common := commonOptions{
+		clear:       opts.clear,
+		prefixIndex: opts.prefixIndex,
+		mrcSamples:  opts.mrcSamples,
+	}
+	opts.clear = 0
+	opts.prefixIndex = false
+	opts.mrcSamples = 0
+	common.report(&opts.optionErrors, name+` has no %s, set it on the Cache wrapping it`)
+}
+
+// applyLowLRUK LowLRUK only has the removal listener, the other settings belong to its history and lru
+func (o Option) applyLowLRUK(opts *lowLRUKOptions) {
+	common := commonOptions{
+		onRemoval: opts.onRemoval,
+	}
+	o.f(&common)
+	opts.onRemoval = common.onRemoval
+	common.onRemoval = nil
+	common.report(&opts.optionErrors, `LowLRUK has no %s, set it on its history and lru`)
+}
+
+// applyLoading LoadingCache has expiry, keyFunc and clock, the other settings belong to the wrapped Cache
+func (o Option) applyLoading(opts *loadingOptions) {
+	common := commonOptions{
+		expiry:  opts.expiry,
+		keyFunc: opts.keyFunc,
+		clock:   opts.clock,
+	}
+	o.f(&common)
+	opts.expiry = common.expiry
+	opts.keyFunc = common.keyFunc
+	opts.clock = common.clock
+	common.expiry = 0
+	common.keyFunc = nil
+	common.clock = nil
+	common.report(&opts.optionErrors, `LoadingCache has no %s, set it on the wrapped Cache`)
+}
+
+// report adds the invalid options to errs, with the settings that are still set as invalid by format.
+// The constructors that wrap other caches clear the settings they have before.
+func (o *commonOptions) report(errs *optionErrors, format string) {
+	for _, message := range o.messages {
+		errs.invalid(message)
+	}
+	set := func(setting string, ok bool) {
+		if ok {
+			errs.invalid(fmt.Sprintf(format, setting))
+		}
+	}
+	set(`capacity`, o.capacity != 0)
+	set(`expiry`, o.expiry != 0)
+	set(`expire after write`, o.expireAfterWrite != 0)
+	set(`expiry policy`, o.expiryPolicy != nil)
+	set(`key func`, o.keyFunc != nil)
+	set(`pin limit`, o.pinLimit != 0)
+	set(`removal listener`, o.onRemoval != nil)
+	set(`clock`, o.clock != nil)
+	set(`clear interval`, o.clear != 0)
+	set(`prefix index`, o.prefixIndex)
+	set(`miss ratio curve`, o.mrcSamples != 0)
+}
+
+// WithCapacity set the maximum amount of data to be cached
+func WithCapacity(capacity int) Option {
+	return newOption(func(o *commonOptions) {
+		if capacity < 1 {
+			o.invalid(fmt.Sprintf(`capacity must > 0, got %d`, capacity))
+			return
+		}
+		o.capacity = capacity
+	})
+}
+
+// WithExpiry expire after access, the deadline is refreshed by every read and write. if <=0, it will not expire due to inactivity.
+//
+// LoadingCache expires a loaded value after expiry.
+func WithExpiry(expiry time.Duration) Option {
+	return newOption(func(o *commonOptions) {
+		o.expiry = expiry
+	})
+}
+
+// WithExpireAfterWrite expire after create or update, reads do not extend it. if <=0, it is disabled.
+func WithExpireAfterWrite(expiry time.Duration) Option {
+	return newOption(func(o *commonOptions) {
+		o.expireAfterWrite = expiry
+	})
+}
+
+// WithExpiryPolicy computes a variable time to live for every entry, it overrides WithExpiry.
+// WithExpireAfterWrite is still applied as a hard limit.
+func WithExpiryPolicy(policy ExpiryPolicy) Option {
+	return newOption(func(o *commonOptions) {
+		o.expiryPolicy = policy
+	})
+}
+
+// WithClearInterval timer clear expired cache, if <=0 not start timer. LowCache reports it as invalid.
+func WithClearInterval(duration time.Duration) Option {
+	return newOption(func(o *commonOptions) {
+		o.clear = duration
+	})
+}
+
+// WithKeyFunc normalises keys before they are used, eg. BytesKey
+func WithKeyFunc(f KeyFunc) Option {
+	return newOption(func(o *commonOptions) {
+		o.keyFunc = f
+	})
+}
+
+// WithPrefixIndex index string keys in a radix tree, so DeletePrefix does not walk the whole cache. LowCache reports it as invalid.
+func WithPrefixIndex(enable bool) Option {
+	return newOption(func(o *commonOptions) {
+		o.prefixIndex = enable
+	})
+}
+
+// WithPinLimit set the fraction of capacity that can be pinned, in [0, 1], at least one entry is always left for eviction
+func WithPinLimit(fraction float64) Option {
+	return newOption(func(o *commonOptions) {
+		if checkPinLimit(&o.optionErrors, fraction) {
+			o.pinLimit = fraction
+		}
+	})
+}
+
+// WithOnRemoval receives every evicted, expired, deleted or replaced entry, it is called while the cache is locked and must not call any method of the cache.
+//
+// A LowCache installs it by SetRemovalListener.
+func WithOnRemoval(listener RemovalListener) Option {
+	return newOption(func(o *commonOptions) {
+		o.onRemoval = listener
+	})
+}
+
+// WithClock set the source of time and of the sweeper ticker, nil is SystemClock
+func WithClock(clock Clock) Option {
+	return newOption(func(o *commonOptions) {
+		o.clock = clock
+	})
+}
+
+// WithMissRatioCurve enable MissRatioCurve, it samples at most samples keys of the reads, 0 disables it, it must not be negative. LowCache reports it as invalid.
+func WithMissRatioCurve(samples int) Option {
+	return newOption(func(o *commonOptions) {
+		if checkMissRatioSamples(&o.optionErrors, samples) {
+			o.mrcSamples = samples
+		}
+	})
+}
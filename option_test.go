@@ -0,0 +1,86 @@
+package gcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOption(t *testing.T) {
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	removed := 0
+	onRemoval := gcache.WithOnRemoval(func(key, value interface{}, reason gcache.RemovalReason) {
+		removed++
+	})
+	capacity := gcache.WithCapacity(2)
+	expiry := gcache.WithExpiry(time.Minute)
+	withClock := gcache.WithClock(clock)
+	clear := gcache.WithClearInterval(0)
+
+	// the same options are accepted by every constructor
+	caches := []fullCache{
+		gcache.NewLRU(capacity, expiry, withClock, clear, onRemoval),
+		gcache.NewFIFO(capacity, expiry, withClock, clear, onRemoval),
+		gcache.NewLFU(capacity, expiry, withClock, clear, onRemoval),
+		// layered with the options of the algorithm
+		gcache.NewLRUK(capacity, expiry, withClock, clear, onRemoval, gcache.WithLRUK(1)),
+	}
+	for _, c := range caches {
+		removed = 0
+		c.Put(1, 1)
+		c.Put(2, 2)
+		c.Put(3, 3)
+		assert.Equal(t, 2, c.Len())
+		assert.Equal(t, 1, removed)
+		info, _ := c.GetEntry(3)
+		assert.Equal(t, time.Minute, info.TTL)
+	}
+	lows := []fullLowCache{
+		full(gcache.NewLowLRU(capacity, expiry, withClock, clear, onRemoval)),
+		full(gcache.NewLowFIFO(capacity, expiry, withClock, clear, onRemoval)),
+		full(gcache.NewLowLFU(capacity, expiry, withClock, clear, onRemoval)),
+	}
+	for _, l := range lows {
+		removed = 0
+		l.Put(1, 1)
+		l.Put(2, 2)
+		l.Put(3, 3)
+		assert.Equal(t, 2, l.Len())
+		assert.Equal(t, 1, removed)
+	}
+	start := clock.Now()
+	clock.Advance(time.Minute)
+	for _, l := range lows {
+		assert.False(t, l.Contains(3))
+	}
+	gcache.NewLowLRUK(lows[0], lows[1], onRemoval, gcache.WithLowLRUK(2))
+
+	// the old names keep working and the last option wins
+	l := gcache.NewLRU(gcache.WithLRUCapacity(1), capacity)
+	l.Put(1, 1)
+	l.Put(2, 2)
+	assert.Equal(t, 2, l.Len())
+	l = gcache.NewLRU(capacity, gcache.WithLRUCapacity(1))
+	l.Put(1, 1)
+	l.Put(2, 2)
+	assert.Equal(t, 1, l.Len())
+
+	loaded := 0
+	loading := gcache.NewLoadingCache(gcache.NewLRU(), func(key interface{}) (interface{}, error) {
+		loaded++
+		return loaded, nil
+	}, expiry, withClock)
+	v, _ := loading.Get(1)
+	assert.Equal(t, 1, v)
+	clock.Advance(time.Minute)
+	v, _ = loading.Get(1)
+	assert.Equal(t, 2, v)
+	assert.Equal(t, start.Add(time.Minute*2), clock.Now())
+
+	assert.Panics(t, func() {
+		gcache.NewLRU(gcache.WithCapacity(0))
+	})
+}
@@ -0,0 +1,26 @@
+package gcache
+
+// Peeker is implemented by Cache and LowCache that read without side effects, all caches provided by gcache implement it.
+type Peeker interface {
+	// Peek return cache value without updating recency, frequency, history or deadline
+	Peek(key interface{}) (value interface{}, exists bool)
+	// Contains reports whether the key is cached without updating recency, frequency, history or deadline
+	Contains(key interface{}) (exists bool)
+}
+
+// peek calls Peek if c implements Peeker, otherwise Get which may update the order of c
+func peek(c LowCache, key interface{}) (value interface{}, exists bool) {
+	if p, ok := c.(Peeker); ok {
+		return p.Peek(key)
+	}
+	return c.Get(key)
+}
+
+// contains calls Contains if c implements Peeker, otherwise Get which may update the order of c
+func contains(c LowCache, key interface{}) (exists bool) {
+	if p, ok := c.(Peeker); ok {
+		return p.Contains(key)
+	}
+	_, exists = c.Get(key)
+	return
+}
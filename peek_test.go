@@ -0,0 +1,90 @@
+package gcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeek(t *testing.T) {
+	// lru order
+	var l fullCache
+	l = gcache.NewLRU(
+		gcache.WithLRUCapacity(3),
+	)
+	for i := 0; i < 3; i++ {
+		l.Put(i, i)
+	}
+	v, exists := l.Peek(0)
+	assert.True(t, exists)
+	assert.Equal(t, 0, v)
+	assert.True(t, l.Contains(0))
+	l.Put(3, 3)
+	assert.False(t, l.Contains(0))
+	_, exists = l.Peek(0)
+	assert.False(t, exists)
+
+	// lfu count
+	l = gcache.NewLFU(
+		gcache.WithLFUCapacity(2),
+	)
+	l.Put(0, 0)
+	l.Put(1, 1)
+	l.Get(0)
+	for i := 0; i < 10; i++ {
+		assert.True(t, l.Contains(1))
+	}
+	l.Put(2, 2)
+	assert.True(t, l.Contains(0))
+	assert.False(t, l.Contains(1))
+
+	// lruk history
+	h := gcache.NewLowLRU(gcache.WithLowLRUCapacity(3))
+	l = gcache.NewLRUK(
+		gcache.WithLRUK(2),
+		gcache.WithLRUKHistory(h),
+	)
+	_, exists = l.Peek(0)
+	assert.False(t, exists)
+	assert.False(t, l.Contains(0))
+	assert.Equal(t, 0, h.Len())
+
+	// expiry
+	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	l = gcache.NewLRU(
+		gcache.WithLRUExpiry(duration),
+		gcache.WithLRUClock(clock),
+	)
+	l.Put(0, 0)
+	clock.Advance(duration / 2)
+	assert.True(t, l.Contains(0))
+	clock.Advance(duration / 2)
+	_, exists = l.Peek(0)
+	assert.False(t, exists)
+	assert.Equal(t, 1, l.Len())
+}
+
+func TestPeekFallback(t *testing.T) {
+	_, ok := interface{}(plainLowCache{}).(gcache.Peeker)
+	assert.False(t, ok)
+
+	l := gcache.NewLRUK(
+		gcache.WithLRUK(1),
+		gcache.WithLRUKLRU(plainLowCache{gcache.NewLowLRU(gcache.WithLowLRUCapacity(2))}),
+	)
+	l.Put(1, 1)
+	l.Put(2, 2)
+	v, exists := l.Peek(1)
+	assert.True(t, exists)
+	assert.Equal(t, 1, v)
+	assert.False(t, l.Contains(3))
+
+	// the lru is read by Get, so 1 is no longer the least recently used
+	l.Put(3, 3)
+	assert.True(t, l.Contains(1))
+	assert.False(t, l.Contains(2))
+}
@@ -0,0 +1,53 @@
+package gcache
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotCached the key is not cached or expired
+	ErrNotCached = errors.New(`gcache: not cached`)
+	// ErrPinLimit the pinned entries reached the limit of the capacity
+	ErrPinLimit = errors.New(`gcache: pin limit reached`)
+	// ErrNotPinnable the LowCache does not implement Pinner
+	ErrNotPinnable = errors.New(`gcache: pinning not supported`)
+)
+
+// Pinner is implemented by Cache and LowCache that can exempt entries from eviction, all caches provided by gcache implement it.
+//
+// Pinned entries are still removed by Delete, Clear and expiry.
+type Pinner interface {
+	// Pin exempts key from eviction, returns ErrNotCached or an error wrapping ErrPinLimit if it cannot be pinned
+	Pin(key interface{}) error
+	// Unpin makes key evictable again, returns false if it is not pinned
+	Unpin(key interface{}) (unpinned bool)
+}
+
+// PinnedPutter is implemented by Cache that put and pin a value at once, all Cache provided by gcache implement it.
+type PinnedPutter interface {
+	// PutPinned put key value to cache and pin it, if an error is returned the value is still put but not pinned
+	PutPinned(key, value interface{}) error
+}
+
+// defaultPinLimit the fraction of capacity that can be pinned by default
+const defaultPinLimit = 0.5
+
+// pinLimit returns the maximum amount of pinned entries, one entry is always left for eviction
+func pinLimit(capacity int, fraction float64) int {
+	limit := int(float64(capacity) * fraction)
+	if limit >= capacity {
+		limit = capacity - 1
+	}
+	return limit
+}
+func checkPinLimit(errs *optionErrors, fraction float64) (valid bool) {
+	if fraction < 0 || fraction > 1 {
+		errs.invalid(fmt.Sprintf(`pin limit must be in [0, 1], got %v`, fraction))
+		return false
+	}
+	return true
+}
+func errPinLimit(limit, capacity int) error {
+	return fmt.Errorf(`%w: %d of capacity %d`, ErrPinLimit, limit, capacity)
+}
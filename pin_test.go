@@ -0,0 +1,99 @@
+package gcache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPin(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(4)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(4)),
+		gcache.NewLFU(gcache.WithLFUCapacity(4)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(4), gcache.WithLRUK(1)),
+	}
+	for _, l := range caches {
+		assert.True(t, errors.Is(l.Pin(1), gcache.ErrNotCached))
+		l.Put(1, 1)
+		l.Put(2, 2)
+		assert.Nil(t, l.Pin(1))
+		assert.Nil(t, l.Pin(1))
+		assert.Nil(t, l.PutPinned(2, 2))
+		// 4 * 0.5
+		l.Put(3, 3)
+		assert.True(t, errors.Is(l.PutPinned(3, 3), gcache.ErrPinLimit))
+		assert.True(t, l.Contains(3))
+		info, _ := l.GetEntry(1)
+		assert.True(t, info.Pinned)
+
+		for i := 10; i < 20; i++ {
+			l.Put(i, i)
+			l.Get(i)
+		}
+		assert.True(t, l.Contains(1))
+		assert.True(t, l.Contains(2))
+		assert.Equal(t, 4, l.Len())
+
+		// deleted and unpinned
+		assert.Equal(t, 1, l.Delete(1))
+		l.Put(1, 1)
+		assert.False(t, l.Unpin(1))
+		assert.True(t, l.Unpin(2))
+		assert.Nil(t, l.Pin(1))
+		for i := 20; i < 30; i++ {
+			l.Put(i, i)
+			l.Get(i)
+		}
+		assert.True(t, l.Contains(1))
+		info, _ = l.GetEntry(2)
+		assert.False(t, info.Pinned)
+
+		l.Clear()
+		l.Put(1, 1)
+		l.Put(2, 2)
+		assert.Nil(t, l.Pin(1))
+		assert.Nil(t, l.Pin(2))
+	}
+
+	// pinned entries still expire
+	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	l := gcache.NewLRU(gcache.WithLRUExpiry(duration), gcache.WithLRUCapacity(4), gcache.WithLRUClock(clock))
+	assert.Nil(t, l.PutPinned(1, 1))
+	clock.Advance(duration)
+	assert.False(t, l.Contains(1))
+	assert.True(t, errors.Is(l.Pin(1), gcache.ErrNotCached))
+	l.Put(1, 1)
+	l.Put(2, 2)
+	assert.Nil(t, l.Pin(1))
+	assert.Nil(t, l.Pin(2))
+
+	// at least one entry is left for eviction
+	l = gcache.NewLRU(gcache.WithLRUCapacity(2), gcache.WithLRUPinLimit(1))
+	assert.Nil(t, l.PutPinned(1, 1))
+	assert.True(t, errors.Is(l.PutPinned(2, 2), gcache.ErrPinLimit))
+	l.Put(3, 3)
+	assert.True(t, l.Contains(1))
+
+	// lru-k moves the history value to lru
+	k := gcache.NewLRUK(gcache.WithLRUK(3), gcache.WithLRUKHistoryOnlyKey(false))
+	k.Put(1, 1)
+	assert.Nil(t, k.Pin(1))
+	info, _ := k.GetEntry(1)
+	assert.Equal(t, "lru", info.Segment)
+	assert.True(t, info.Pinned)
+
+	// pinned entries are not evicted by the quota
+	l = gcache.NewLRU()
+	ns := l.Namespace("a", gcache.WithNamespaceQuota(2)).(fullCache)
+	assert.Nil(t, ns.PutPinned(1, 1))
+	ns.Put(2, 2)
+	ns.Put(3, 3)
+	assert.True(t, ns.Contains(1))
+	assert.False(t, ns.Contains(2))
+}
@@ -0,0 +1,178 @@
+package gcache
+
+import (
+	"sort"
+	"strings"
+)
+
+// radixTree indexes string keys, so keys can be found by prefix without a full scan
+type radixTree struct {
+	root radixNode
+	size int
+}
+
+type radixNode struct {
+	prefix string
+	// children sorted by the first byte of prefix
+	children []*radixNode
+	leaf     bool
+	// key is the original key of the leaf
+	key interface{}
+}
+
+func newRadixTree() *radixTree {
+	return &radixTree{}
+}
+
+// child returns the child starting with b, if not found returns nil and the index to insert
+func (n *radixNode) child(b byte) (*radixNode, int) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= b
+	})
+	if i < len(n.children) && n.children[i].prefix[0] == b {
+		return n.children[i], i
+	}
+	return nil, i
+}
+func (n *radixNode) insertChild(i int, child *radixNode) {
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+}
+func (n *radixNode) removeChild(i int) {
+	copy(n.children[i:], n.children[i+1:])
+	n.children[len(n.children)-1] = nil
+	n.children = n.children[:len(n.children)-1]
+}
+
+// mergeChild merges the only child into n
+func (n *radixNode) mergeChild() {
+	child := n.children[0]
+	n.prefix += child.prefix
+	n.children = child.children
+	n.leaf = child.leaf
+	n.key = child.key
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// Len returns the number of keys
+func (t *radixTree) Len() int {
+	return t.size
+}
+
+// Insert s and the original key
+func (t *radixTree) Insert(s string, key interface{}) {
+	n := &t.root
+	for {
+		if len(s) == 0 {
+			if !n.leaf {
+				n.leaf = true
+				t.size++
+			}
+			n.key = key
+			return
+		}
+		child, i := n.child(s[0])
+		if child == nil {
+			n.insertChild(i, &radixNode{
+				prefix: s,
+				leaf:   true,
+				key:    key,
+			})
+			t.size++
+			return
+		}
+		common := commonPrefix(child.prefix, s)
+		if common < len(child.prefix) {
+			// split child
+			split := &radixNode{
+				prefix:   child.prefix[:common],
+				children: []*radixNode{child},
+			}
+			child.prefix = child.prefix[common:]
+			n.children[i] = split
+			child = split
+		}
+		n = child
+		s = s[common:]
+	}
+}
+
+// Delete s, returns false if not found
+func (t *radixTree) Delete(s string) bool {
+	var (
+		parent *radixNode
+		index  int
+		n      = &t.root
+	)
+	for len(s) != 0 {
+		child, i := n.child(s[0])
+		if child == nil || !strings.HasPrefix(s, child.prefix) {
+			return false
+		}
+		parent, index, n = n, i, child
+		s = s[len(child.prefix):]
+	}
+	if !n.leaf {
+		return false
+	}
+	n.leaf = false
+	n.key = nil
+	t.size--
+	if parent == nil {
+		return true
+	}
+	// compact the tree
+	switch len(n.children) {
+	case 0:
+		parent.removeChild(index)
+		if parent != &t.root && !parent.leaf && len(parent.children) == 1 {
+			parent.mergeChild()
+		}
+	case 1:
+		n.mergeChild()
+	}
+	return true
+}
+
+// WalkPrefix calls f for every key starting with prefix
+func (t *radixTree) WalkPrefix(prefix string, f func(key interface{})) {
+	n := &t.root
+	s := prefix
+	for len(s) != 0 {
+		child, _ := n.child(s[0])
+		if child == nil {
+			return
+		}
+		if strings.HasPrefix(s, child.prefix) {
+			s = s[len(child.prefix):]
+		} else if strings.HasPrefix(child.prefix, s) {
+			s = ``
+		} else {
+			return
+		}
+		n = child
+	}
+	n.walk(f)
+}
+func (n *radixNode) walk(f func(key interface{})) {
+	if n.leaf {
+		f(n.key)
+	}
+	for _, child := range n.children {
+		child.walk(f)
+	}
+}
+
+// Clear remove all keys
+func (t *radixTree) Clear() {
+	t.root = radixNode{}
+	t.size = 0
+}
@@ -0,0 +1,24 @@
+package gcache
+
+// Ranger is implemented by Cache and LowCache that iterate their entries, all caches provided by gcache implement it.
+type Ranger interface {
+	// Range calls f for each live entry in eviction order, if f returns false stop the iteration.
+	//
+	// f must not modify the cache, a Cache of gcache holds its lock for the whole walk so f must not call any method of it.
+	Range(f func(key, value interface{}) bool)
+}
+
+// Lister is implemented by Cache that return snapshots of their entries, all Cache provided by gcache implement it.
+type Lister interface {
+	// Keys returns a snapshot of the live keys in eviction order
+	Keys() []interface{}
+	// Values returns a snapshot of the live values in eviction order
+	Values() []interface{}
+}
+
+// rangeLow calls Range if c implements Ranger, otherwise nothing is visited
+func rangeLow(c LowCache, f func(key, value interface{}) bool) {
+	if r, ok := c.(Ranger); ok {
+		r.Range(f)
+	}
+}
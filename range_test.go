@@ -0,0 +1,105 @@
+package gcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	// lru
+	var l fullCache
+	l = gcache.NewLRU(
+		gcache.WithLRUCapacity(3),
+	)
+	for i := 0; i < 3; i++ {
+		l.Put(i, i*10)
+	}
+	l.Get(0)
+	assert.Equal(t, []interface{}{1, 2, 0}, l.Keys())
+	assert.Equal(t, []interface{}{10, 20, 0}, l.Values())
+	var keys []interface{}
+	l.Range(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return len(keys) < 2
+	})
+	assert.Equal(t, []interface{}{1, 2}, keys)
+
+	// fifo
+	l = gcache.NewFIFO(
+		gcache.WithFIFOCapacity(3),
+	)
+	for i := 0; i < 3; i++ {
+		l.Put(i, i)
+	}
+	l.Get(0)
+	assert.Equal(t, []interface{}{0, 1, 2}, l.Keys())
+
+	// lfu
+	l = gcache.NewLFU(
+		gcache.WithLFUCapacity(3),
+	)
+	for i := 0; i < 3; i++ {
+		l.Put(i, i)
+		for j := 0; j < 3-i; j++ {
+			l.Get(i)
+		}
+	}
+	assert.Equal(t, []interface{}{2, 1, 0}, l.Keys())
+
+	// lruk
+	lruk := gcache.NewLRUK(
+		gcache.WithLRUK(3),
+		gcache.WithLRUKHistoryOnlyKey(false),
+	)
+	lruk.Put(0, 0)
+	lruk.Put(0, 0)
+	lruk.Put(0, 0)
+	lruk.Put(1, 1)
+	assert.Equal(t, []interface{}{0, 1}, lruk.Keys())
+	var history []interface{}
+	lruk.RangeHistory(func(key, value interface{}, count int) bool {
+		history = append(history, key, value, count)
+		return true
+	})
+	assert.Equal(t, []interface{}{1, 1, 1}, history)
+
+	// expiry
+	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	l = gcache.NewLRU(
+		gcache.WithLRUExpiry(duration),
+		gcache.WithLRUClock(clock),
+	)
+	l.Put(0, 0)
+	clock.Advance(duration)
+	l.Put(1, 1)
+	assert.Equal(t, []interface{}{1}, l.Keys())
+}
+
+func TestRangeFallback(t *testing.T) {
+	_, ok := interface{}(plainLowCache{}).(gcache.Ranger)
+	assert.False(t, ok)
+	_, ok = interface{}(plainCache{}).(gcache.Ranger)
+	assert.False(t, ok)
+
+	// the entries of a LowCache without Ranger are not visited
+	l := gcache.NewLRUK(
+		gcache.WithLRUK(1),
+		gcache.WithLRUKLRU(plainLowCache{gcache.NewLowLRU()}),
+	)
+	l.Put(1, 1)
+	assert.Equal(t, 1, l.Len())
+	assert.Empty(t, l.Keys())
+
+	// every entry of a Cache without Ranger is counted as a value
+	c := gcache.NewLoadingCache(plainCache{gcache.NewLRU()}, func(key interface{}) (interface{}, error) {
+		return nil, gcache.ErrNotFound
+	}, gcache.WithLoadingNotFoundExpiry(time.Minute))
+	c.Get(1)
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, 0, c.Tombstones())
+}
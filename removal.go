@@ -0,0 +1,66 @@
+package gcache
+
+// RemovalReason why an entry was removed from the cache
+type RemovalReason uint8
+
+const (
+	// RemovalEvicted evicted because the capacity limit was reached
+	RemovalEvicted RemovalReason = iota + 1
+	// RemovalExpired removed after its deadline
+	RemovalExpired
+	// RemovalDeleted removed by Delete or Clear
+	RemovalDeleted
+	// RemovalReplaced the value was replaced by Put, the key is still cached
+	RemovalReplaced
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovalEvicted:
+		return `evicted`
+	case RemovalExpired:
+		return `expired`
+	case RemovalDeleted:
+		return `deleted`
+	case RemovalReplaced:
+		return `replaced`
+	}
+	return `unknown`
+}
+
+// RemovalListener receives the entries removed from a LowCache, it is called while the cache is locked and must not call any method of the cache
+type RemovalListener func(key, value interface{}, reason RemovalReason)
+
+// RemovalNotifier is implemented by LowCache that reports removed entries, all LowCache provided by gcache implement it.
+type RemovalNotifier interface {
+	// SetRemovalListener replace the listener, nil removes it
+	SetRemovalListener(listener RemovalListener)
+}
+
+// keyedListener is a RemovalListener that also receives k, the key normalised by KeyFunc when the entry was put
+type keyedListener func(k, key, value interface{}, reason RemovalReason)
+
+// keyed returns the keyedListener that calls f, nil if f is nil
+func (f RemovalListener) keyed() keyedListener {
+	if f == nil {
+		return nil
+	}
+	return func(k, key, value interface{}, reason RemovalReason) {
+		f(key, value, reason)
+	}
+}
+
+// setListener installs listener on impl, k is the key passed by the caller if impl does not implement keyedCache
+func setListener(impl LowCache, listener keyedListener) {
+	if kc, ok := impl.(keyedCache); ok {
+		kc.setKeyedListener(listener)
+	} else if n, ok := impl.(RemovalNotifier); ok {
+		if listener == nil {
+			n.SetRemovalListener(nil)
+		} else {
+			n.SetRemovalListener(func(key, value interface{}, reason RemovalReason) {
+				listener(key, key, value, reason)
+			})
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package gcache_test
+
+import (
+	"testing"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+)
+
+func TestLowCacheSuite(t *testing.T) {
+	factories := map[string]gcachetest.LowCacheFactory{
+		`LowLRU`: func(conf gcachetest.Config) gcache.LowCache {
+			return gcache.NewLowLRU(
+				gcache.WithLowLRUCapacity(conf.Capacity),
+				gcache.WithLowLRUExpiry(conf.Expiry),
+				gcache.WithLowLRUClock(conf.Clock),
+			)
+		},
+		`LowFIFO`: func(conf gcachetest.Config) gcache.LowCache {
+			return gcache.NewLowFIFO(
+				gcache.WithLowFIFOCapacity(conf.Capacity),
+				gcache.WithLowFIFOExpiry(conf.Expiry),
+				gcache.WithLowFIFOClock(conf.Clock),
+			)
+		},
+		`LowLFU`: func(conf gcachetest.Config) gcache.LowCache {
+			return gcache.NewLowLFU(
+				gcache.WithLowLFUCapacity(conf.Capacity),
+				gcache.WithLowLFUExpiry(conf.Expiry),
+				gcache.WithLowLFUClock(conf.Clock),
+			)
+		},
+		`LowLRUK`: func(conf gcachetest.Config) gcache.LowCache {
+			return gcache.NewLowLRUK(
+				gcache.NewLowLRU(
+					gcache.WithLowLRUCapacity(conf.Capacity/2),
+					gcache.WithLowLRUExpiry(conf.Expiry),
+					gcache.WithLowLRUClock(conf.Clock),
+				),
+				gcache.NewLowLRU(
+					gcache.WithLowLRUCapacity(conf.Capacity/2),
+					gcache.WithLowLRUExpiry(conf.Expiry),
+					gcache.WithLowLRUClock(conf.Clock),
+				),
+				gcache.WithLowLRUKHistoryOnlyKey(false),
+			)
+		},
+		`LowLRUK/k=1`: func(conf gcachetest.Config) gcache.LowCache {
+			return gcache.NewLowLRUK(nil,
+				gcache.NewLowLFU(
+					gcache.WithLowLFUCapacity(conf.Capacity),
+					gcache.WithLowLFUExpiry(conf.Expiry),
+					gcache.WithLowLFUClock(conf.Clock),
+				),
+				gcache.WithLowLRUK(1),
+			)
+		},
+	}
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			gcachetest.RunLowCacheSuite(t, factory)
+		})
+	}
+	// the history only saves keys, a value is cached by the k access
+	t.Run(`LowLRUK/historyOnlyKey`, func(t *testing.T) {
+		gcachetest.RunLowCacheSuite(t, func(conf gcachetest.Config) gcache.LowCache {
+			return gcache.NewLowLRUK(
+				gcache.NewLowLRU(
+					gcache.WithLowLRUCapacity(conf.Capacity*10),
+					gcache.WithLowLRUExpiry(conf.Expiry),
+					gcache.WithLowLRUClock(conf.Clock),
+				),
+				gcache.NewLowLRU(
+					gcache.WithLowLRUCapacity(conf.Capacity),
+					gcache.WithLowLRUExpiry(conf.Expiry),
+					gcache.WithLowLRUClock(conf.Clock),
+				),
+			)
+		}, gcachetest.WithAdmission(2))
+	})
+}
+
+func TestCacheSuite(t *testing.T) {
+	factories := map[string]gcachetest.CacheFactory{
+		`LRU`: func(conf gcachetest.Config) gcache.Cache {
+			return gcache.NewLRU(
+				gcache.WithLRUCapacity(conf.Capacity),
+				gcache.WithLRUExpiry(conf.Expiry),
+				gcache.WithLRUClock(conf.Clock),
+			)
+		},
+		`FIFO`: func(conf gcachetest.Config) gcache.Cache {
+			return gcache.NewFIFO(
+				gcache.WithFIFOCapacity(conf.Capacity),
+				gcache.WithFIFOExpiry(conf.Expiry),
+				gcache.WithFIFOClock(conf.Clock),
+			)
+		},
+		`LFU`: func(conf gcachetest.Config) gcache.Cache {
+			return gcache.NewLFU(
+				gcache.WithLFUCapacity(conf.Capacity),
+				gcache.WithLFUExpiry(conf.Expiry),
+				gcache.WithLFUClock(conf.Clock),
+			)
+		},
+		`LRUK/k=1`: func(conf gcachetest.Config) gcache.Cache {
+			return gcache.NewLRUK(
+				gcache.WithLRUK(1),
+				gcache.WithLRUKCapacity(conf.Capacity),
+				gcache.WithLRUKExpiry(conf.Expiry),
+				gcache.WithLRUKClock(conf.Clock),
+			)
+		},
+		`LRUK/history`: func(conf gcachetest.Config) gcache.Cache {
+			return gcache.NewLRUK(
+				gcache.WithLRUKCapacity(conf.Capacity/2),
+				gcache.WithLRUKHistoryOnlyKey(false),
+				gcache.WithLRUKExpiry(conf.Expiry),
+				gcache.WithLRUKClock(conf.Clock),
+			)
+		},
+		`Namespace`: func(conf gcachetest.Config) gcache.Cache {
+			return gcache.NewLRU(
+				gcache.WithLRUCapacity(conf.Capacity),
+				gcache.WithLRUExpiry(conf.Expiry),
+				gcache.WithLRUClock(conf.Clock),
+			).Namespace(`suite`)
+		},
+	}
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			gcachetest.RunCacheSuite(t, factory)
+		})
+	}
+	t.Run(`LRUK/historyOnlyKey`, func(t *testing.T) {
+		gcachetest.RunCacheSuite(t, func(conf gcachetest.Config) gcache.Cache {
+			return gcache.NewLRUK(
+				gcache.WithLRUKCapacity(conf.Capacity),
+				gcache.WithLRUKExpiry(conf.Expiry),
+				gcache.WithLRUKClock(conf.Clock),
+			)
+		}, gcachetest.WithAdmission(2))
+	})
+}
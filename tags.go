@@ -0,0 +1,106 @@
+package gcache
+
+// Tagger is implemented by Cache that invalidate keys by tags, all Cache provided by gcache implement it.
+type Tagger interface {
+	// PutWithTags put key value to cache and associate it with tags, the tags replace the previous ones of key.
+	//
+	// Tags are kept until the key is evicted, expired or deleted, Put without tags does not change them.
+	PutWithTags(key, value interface{}, tags ...interface{})
+	// InvalidateTag deletes every key associated with tag, returns the number of deleted keys
+	InvalidateTag(tag interface{}) (changed int)
+}
+
+// tagIndex maps tags to keys, keys are normalised by keyFunc
+type tagIndex struct {
+	// tag -> normalised key -> key
+	keys map[interface{}]map[interface{}]interface{}
+	// normalised key -> tags
+	tags    map[interface{}][]interface{}
+	keyFunc KeyFunc
+}
+
+func newTagIndex(keyFunc KeyFunc) *tagIndex {
+	return &tagIndex{
+		keys:    make(map[interface{}]map[interface{}]interface{}),
+		tags:    make(map[interface{}][]interface{}),
+		keyFunc: keyFunc,
+	}
+}
+
+// Set replace the tags of key
+func (t *tagIndex) Set(key interface{}, tags []interface{}) {
+	k, ok := mapKey(t.keyFunc, key)
+	if !ok {
+		return
+	}
+	t.remove(k)
+	saved := make([]interface{}, 0, len(tags))
+	for _, tag := range tags {
+		if !isHashable(tag) {
+			continue
+		}
+		keys := t.keys[tag]
+		if keys == nil {
+			keys = make(map[interface{}]interface{})
+			t.keys[tag] = keys
+		}
+		if _, exists := keys[k]; !exists {
+			keys[k] = key
+			saved = append(saved, tag)
+		}
+	}
+	if len(saved) != 0 {
+		t.tags[k] = saved
+	}
+}
+
+// Remove all tags of key
+func (t *tagIndex) Remove(key interface{}) {
+	if len(t.tags) == 0 {
+		return
+	}
+	k, ok := mapKey(t.keyFunc, key)
+	if ok {
+		t.remove(k)
+	}
+}
+func (t *tagIndex) remove(k interface{}) {
+	tags, exists := t.tags[k]
+	if !exists {
+		return
+	}
+	delete(t.tags, k)
+	for _, tag := range tags {
+		keys := t.keys[tag]
+		delete(keys, k)
+		if len(keys) == 0 {
+			delete(t.keys, tag)
+		}
+	}
+}
+
+// Keys returns a copy of the keys associated with tag
+func (t *tagIndex) Keys(tag interface{}) []storedKey {
+	if !isHashable(tag) {
+		return nil
+	}
+	keys := t.keys[tag]
+	if len(keys) == 0 {
+		return nil
+	}
+	vals := make([]storedKey, 0, len(keys))
+	for k, key := range keys {
+		vals = append(vals, storedKey{k, key})
+	}
+	return vals
+}
+
+// Clear remove all tags
+func (t *tagIndex) Clear() {
+	for k := range t.keys {
+		delete(t.keys, k)
+	}
+	for k := range t.tags {
+		delete(t.tags, k)
+	}
+}
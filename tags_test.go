@@ -0,0 +1,62 @@
+package gcache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/powerpuffpenguin/gcache/gcachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	caches := []fullCache{
+		gcache.NewLRU(gcache.WithLRUCapacity(3)),
+		gcache.NewFIFO(gcache.WithFIFOCapacity(3)),
+		gcache.NewLFU(gcache.WithLFUCapacity(3)),
+		gcache.NewLRUK(gcache.WithLRUKCapacity(3), gcache.WithLRUK(1)),
+	}
+	for _, l := range caches {
+		l.PutWithTags("page/1", 1, "user/1", "user/2")
+		l.PutWithTags("page/2", 2, "user/2")
+		l.PutWithTags("page/3", 3, "user/3")
+		assert.Equal(t, 2, l.InvalidateTag("user/2"))
+		assert.Equal(t, 1, l.Len())
+		assert.Equal(t, 0, l.InvalidateTag("user/1"))
+
+		// eviction removes the tags
+		l.PutWithTags("page/4", 4, "user/4")
+		l.PutWithTags("page/5", 5, "user/4")
+		l.Put("page/6", 6)
+		assert.False(t, l.Contains("page/3"))
+		l.Put("page/3", 3)
+		assert.Equal(t, 0, l.InvalidateTag("user/3"))
+		assert.True(t, l.Contains("page/3"))
+
+		// retag
+		l.PutWithTags("page/3", 3, "user/5")
+		assert.Equal(t, 1, l.InvalidateTag("user/5"))
+		l.Clear()
+		assert.Equal(t, 0, l.InvalidateTag("user/4"))
+	}
+
+	// expiry
+	duration := time.Millisecond * 10
+	clock := gcachetest.NewFakeClock(time.Unix(0, 0))
+	var l fullCache = gcache.NewLRU(gcache.WithLRUExpiry(duration), gcache.WithLRUClock(clock))
+	l.PutWithTags(1, 1, "a")
+	clock.Advance(duration)
+	l.Put(1, 1)
+	assert.Equal(t, 0, l.InvalidateTag("a"))
+
+	// lru-k keeps the tags when the key moves from history to lru
+	l = gcache.NewLRUK(
+		gcache.WithLRUK(2),
+		gcache.WithLRUKHistoryOnlyKey(false),
+	)
+	l.PutWithTags(1, 1, "a")
+	l.Get(1)
+	l.Get(1)
+	assert.Equal(t, 1, l.InvalidateTag("a"))
+	assert.False(t, l.Contains(1))
+}
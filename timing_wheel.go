@@ -0,0 +1,150 @@
+package gcache
+
+const (
+	// every level has 64 buckets
+	wheelBits = 6
+	wheelSize = 1 << wheelBits
+	wheelMask = wheelSize - 1
+	// level 0 bucket spans 2^20ns ~1ms, then ~67ms ~4.3s ~4.6m ~4.9h, the last level covers ~13d
+	wheelTickBits = 20
+	wheelLevels   = 5
+)
+
+// timerNode links a value into a bucket of the timing wheel
+type timerNode struct {
+	prev, next *timerNode
+	value      cacheValue
+}
+
+func (n *timerNode) unlink() {
+	if n.next != nil {
+		n.prev.next = n.next
+		n.next.prev = n.prev
+		n.prev = nil
+		n.next = nil
+	}
+}
+
+// timingWheel a hierarchical timing wheel that expires values in amortised O(1) regardless of the order of their deadlines.
+//
+// Values whose deadline is far away are saved in the higher levels and cascade to the lower levels as the time advances.
+type timingWheel struct {
+	buckets [wheelLevels][wheelSize]timerNode
+	now     int64
+}
+
+func newTimingWheel(now int64) *timingWheel {
+	w := &timingWheel{
+		now: now,
+	}
+	w.Clear()
+	return w
+}
+
+func wheelShift(level int) uint {
+	return uint(wheelTickBits + level*wheelBits)
+}
+
+// Schedule (re)insert v by its deadline, values that never expire are only removed from the wheel
+func (w *timingWheel) Schedule(v cacheValue) {
+	node := v.Timer()
+	node.unlink()
+	deadline, _ := v.GetDeadline()
+	if deadline == 0 {
+		return
+	}
+	node.value = v
+
+	t := deadline
+	if t < w.now {
+		t = w.now
+	}
+	bucket := w.bucket(t)
+	node.prev = bucket.prev
+	node.next = bucket
+	bucket.prev.next = node
+	bucket.prev = node
+}
+func (w *timingWheel) bucket(t int64) *timerNode {
+	duration := t - w.now
+	for level := 0; level < wheelLevels-1; level++ {
+		if duration < 1<<wheelShift(level+1) {
+			return &w.buckets[level][(t>>wheelShift(level))&wheelMask]
+		}
+	}
+	// beyond the last level, saved in the bucket that is reached last and rescheduled from there
+	level := wheelLevels - 1
+	span := int64(1) << wheelShift(level+1)
+	if duration >= span {
+		t = w.now + span - 1
+	}
+	return &w.buckets[level][(t>>wheelShift(level))&wheelMask]
+}
+
+// Remove v from the wheel
+func (w *timingWheel) Remove(v cacheValue) {
+	v.Timer().unlink()
+}
+
+// Advance the wheel to now and call expired for every value whose deadline has passed.
+func (w *timingWheel) Advance(now int64, expired func(v cacheValue)) {
+	prev := w.now
+	w.now = now
+	for level := 0; level < wheelLevels; level++ {
+		shift := wheelShift(level)
+		prevTicks := prev >> shift
+		delta := w.now>>shift - prevTicks
+		// the current bucket of level 0 is always scanned, its deadlines may pass within the tick.
+		// A higher level only holds the ticks after its current one, it is scanned when its tick moves
+		if delta < 0 || delta == 0 && level > 0 {
+			break
+		}
+		w.expire(level, prevTicks, delta, expired)
+	}
+}
+func (w *timingWheel) expire(level int, prevTicks, delta int64, expired func(v cacheValue)) {
+	var start, end int64
+	if delta >= wheelSize {
+		end = wheelSize
+	} else {
+		start = prevTicks & wheelMask
+		end = start + delta + 1
+	}
+	var (
+		bucket     *timerNode
+		node, next *timerNode
+		v          cacheValue
+		deadline   int64
+	)
+	for i := start; i < end; i++ {
+		bucket = &w.buckets[level][i&wheelMask]
+		node = bucket.next
+		// detach the bucket, values not yet expired will be rescheduled
+		bucket.prev = bucket
+		bucket.next = bucket
+		for node != bucket {
+			next = node.next
+			node.prev = nil
+			node.next = nil
+			v = node.value
+			deadline, _ = v.GetDeadline()
+			if deadline <= w.now {
+				expired(v)
+			} else {
+				w.Schedule(v)
+			}
+			node = next
+		}
+	}
+}
+
+// Clear remove all values from the wheel
+func (w *timingWheel) Clear() {
+	for level := 0; level < wheelLevels; level++ {
+		for i := 0; i < wheelSize; i++ {
+			bucket := &w.buckets[level][i]
+			bucket.prev = bucket
+			bucket.next = bucket
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package gcache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/gcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryNew(t *testing.T) {
+	messages := []string{
+		`capacity must > 0, got 0`,
+		`pin limit must be in [0, 1], got 2`,
+	}
+	expectError := func(e error) {
+		var oe *gcache.OptionError
+		if assert.True(t, errors.As(e, &oe), e) {
+			assert.Equal(t, messages, oe.Messages)
+		}
+		assert.True(t, errors.Is(e, gcache.ErrInvalidConfig))
+	}
+	capacity := gcache.WithCapacity(0)
+	pinLimit := gcache.WithPinLimit(2)
+
+	lru, e := gcache.TryNewLRU(capacity, pinLimit)
+	assert.Nil(t, lru)
+	expectError(e)
+	fifo, e := gcache.TryNewFIFO(capacity, pinLimit)
+	assert.Nil(t, fifo)
+	expectError(e)
+	lfu, e := gcache.TryNewLFU(capacity, pinLimit)
+	assert.Nil(t, lfu)
+	expectError(e)
+	_, e = gcache.TryNewLowLRU(capacity, pinLimit)
+	expectError(e)
+	_, e = gcache.TryNewLowFIFO(capacity, pinLimit)
+	expectError(e)
+	_, e = gcache.TryNewLowLFU(capacity, pinLimit)
+	expectError(e)
+
+	// the options of the algorithm are collected too
+	lruk, e := gcache.TryNewLRUK(gcache.WithLRUKCapacity(-1), gcache.WithLRUK(0))
+	assert.Nil(t, lruk)
+	assert.EqualError(t, e, `gcache: invalid config: lru capacity must > 0, got -1; lru-k k must > 0, got 0`)
+	_, e = gcache.TryNewLowLRUK(nil, gcache.NewLowLRU(), gcache.WithLowLRUK(0))
+	assert.EqualError(t, e, `gcache: invalid config: lru-k k must > 0, got 0`)
+
+	// the settings of the wrapped caches
+	_, e = gcache.TryNewLowLRUK(nil, gcache.NewLowLRU(), capacity, pinLimit)
+	expectError(e)
+	_, e = gcache.TryNewLowLRUK(nil, gcache.NewLowLRU(), gcache.WithCapacity(2), gcache.WithExpiry(time.Minute))
+	assert.EqualError(t, e, `gcache: invalid config: LowLRUK has no capacity, set it on its history and lru; LowLRUK has no expiry, set it on its history and lru`)
+	removed := 0
+	lowLRUK, e := gcache.TryNewLowLRUK(nil, gcache.NewLowLRU(), gcache.WithOnRemoval(func(key, value interface{}, reason gcache.RemovalReason) {
+		removed++
+	}))
+	if assert.NoError(t, e) {
+		lowLRUK.Put(1, 1)
+		lowLRUK.Delete(1)
+		assert.Equal(t, 1, removed)
+	}
+	loader := func(key interface{}) (interface{}, error) {
+		return key, nil
+	}
+	loading, e := gcache.TryNewLoadingCache(gcache.NewLRU(), loader, capacity, pinLimit)
+	assert.Nil(t, loading)
+	expectError(e)
+	loading, e = gcache.TryNewLoadingCache(gcache.NewLRU(), loader, gcache.WithExpiry(time.Minute))
+	if assert.NoError(t, e) {
+		v, e := loading.Get(1)
+		assert.NoError(t, e)
+		assert.Equal(t, 1, v)
+	}
+	assert.PanicsWithError(t, `gcache: invalid config: capacity must > 0, got -5`, func() {
+		gcache.NewLoadingCache(gcache.NewLRU(), loader, gcache.WithCapacity(-5))
+	})
+	assert.PanicsWithError(t, `gcache: invalid config: LoadingCache has no capacity, set it on the wrapped Cache`, func() {
+		gcache.NewLoadingCache(gcache.NewLRU(), loader, gcache.WithCapacity(5), gcache.WithExpiry(time.Minute))
+	})
+
+	// the settings of Cache
+	clearInterval := gcache.WithClearInterval(time.Minute)
+	prefixIndex := gcache.WithPrefixIndex(true)
+	mrc := gcache.WithMissRatioCurve(8)
+	_, e = gcache.TryNewLowLRU(clearInterval, prefixIndex, mrc)
+	assert.EqualError(t, e, `gcache: invalid config: LowLRU has no clear interval, set it on the Cache wrapping it; LowLRU has no prefix index, set it on the Cache wrapping it; LowLRU has no miss ratio curve, set it on the Cache wrapping it`)
+	_, e = gcache.TryNewLowFIFO(clearInterval, prefixIndex, mrc)
+	assert.EqualError(t, e, `gcache: invalid config: LowFIFO has no clear interval, set it on the Cache wrapping it; LowFIFO has no prefix index, set it on the Cache wrapping it; LowFIFO has no miss ratio curve, set it on the Cache wrapping it`)
+	_, e = gcache.TryNewLowLFU(clearInterval, prefixIndex, mrc)
+	assert.EqualError(t, e, `gcache: invalid config: LowLFU has no clear interval, set it on the Cache wrapping it; LowLFU has no prefix index, set it on the Cache wrapping it; LowLFU has no miss ratio curve, set it on the Cache wrapping it`)
+	_, e = gcache.TryNewLowLRU(gcache.WithClearInterval(0), gcache.WithPrefixIndex(false), gcache.WithMissRatioCurve(0))
+	assert.NoError(t, e)
+
+	// miss ratio curve samples
+	const samples = `gcache: invalid config: miss ratio curve samples must >= 0, got -1`
+	lru, e = gcache.TryNewLRU(gcache.WithMissRatioCurve(-1))
+	assert.Nil(t, lru)
+	assert.EqualError(t, e, samples)
+	_, e = gcache.TryNewLRU(gcache.WithLRUMissRatioCurve(-1))
+	assert.EqualError(t, e, samples)
+	_, e = gcache.TryNewFIFO(gcache.WithFIFOMissRatioCurve(-1))
+	assert.EqualError(t, e, samples)
+	_, e = gcache.TryNewLFU(gcache.WithLFUMissRatioCurve(-1))
+	assert.EqualError(t, e, samples)
+	_, e = gcache.TryNewLRUK(gcache.WithLRUKMissRatioCurve(-1))
+	assert.EqualError(t, e, samples)
+
+	// valid options
+	lru, e = gcache.TryNewLRU(gcache.WithCapacity(2), gcache.WithPinLimit(0.5))
+	if assert.NoError(t, e) {
+		lru.Put(1, 1)
+		assert.Equal(t, 1, lru.Len())
+	}
+
+	// NewXXX still panic
+	assert.PanicsWithError(t, `gcache: invalid config: fifo capacity must > 0, got 0`, func() {
+		gcache.NewFIFO(gcache.WithFIFOCapacity(0))
+	})
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	_, e := gcache.New(gcache.Config{
+		Algorithm: `arc`,
+		Capacity:  -1,
+		K:         -2,
+	})
+	assert.True(t, errors.Is(e, gcache.ErrInvalidConfig))
+	var oe *gcache.OptionError
+	if assert.True(t, errors.As(e, &oe)) {
+		// other tests may register algorithms
+		if assert.Len(t, oe.Messages, 3) {
+			assert.Equal(t, `capacity must > 0, got -1`, oe.Messages[0])
+			assert.Equal(t, `k must > 0, got -2`, oe.Messages[1])
+			assert.Contains(t, oe.Messages[2], `unknown algorithm "arc"`)
+		}
+	}
+}
@@ -1,37 +1,64 @@
 package gcache
 
-import "time"
-
 type cacheValue interface {
+	// MapKey returns the key normalised by KeyFunc when the value was put, it is the key of the internal map
+	MapKey() interface{}
 	GetKey() interface{}
 	GetValue() interface{}
 	SetKey(key interface{})
 	SetValue(val interface{})
-	IsDeleted() bool
-	SetDeadline(deadline time.Time)
+	// IsDeleted reports whether the deadline has passed at now in unix nanoseconds
+	IsDeleted(now int64) bool
+	// GetDeadline returns the effective deadline and the expire after write deadline in unix nanoseconds, zero never expires
+	GetDeadline() (deadline, expire int64)
+	SetDeadline(deadline, expire int64)
+	// Timer returns the node that links the value into the timing wheel
+	Timer() *timerNode
+	// Touch records an access at now in unix nanoseconds, create also resets the creation time
+	Touch(now int64, create bool)
+	// Touched returns the creation and last access time in unix nanoseconds
+	Touched() (created, accessed int64)
+	// Pinned reports whether the value is exempt from eviction
+	Pinned() bool
+	SetPinned(pinned bool)
 }
 
-func newValue(key, val interface{}, expiry time.Duration) cacheValue {
-	if expiry > 0 {
-		return &deadlineValue{
+func newValue(k, key, val interface{}, e expiration, now int64) cacheValue {
+	if e.enabled() {
+		v := &deadlineValue{
 			baseValue: baseValue{
-				key:   key,
-				value: val,
+				k:        k,
+				key:      key,
+				value:    val,
+				created:  now,
+				accessed: now,
 			},
-			deadline: time.Now().Add(expiry),
 		}
+		e.Apply(v, expiryCreate, now)
+		return v
 	}
 	return &baseValue{
-		key:   key,
-		value: val,
+		k:        k,
+		key:      key,
+		value:    val,
+		created:  now,
+		accessed: now,
 	}
 }
 
 type baseValue struct {
-	key   interface{}
-	value interface{}
+	// k the normalised key, key may be modified by the caller after it was put, eg. a reused []byte
+	k        interface{}
+	key      interface{}
+	value    interface{}
+	created  int64
+	accessed int64
+	pinned   bool
 }
 
+func (v *baseValue) MapKey() interface{} {
+	return v.k
+}
 func (v *baseValue) GetKey() interface{} {
 	return v.key
 }
@@ -44,21 +71,51 @@ func (v *baseValue) SetKey(key interface{}) {
 func (v *baseValue) SetValue(val interface{}) {
 	v.value = val
 }
-func (v *baseValue) IsDeleted() bool {
+func (v *baseValue) IsDeleted(now int64) bool {
 	return false
 }
-func (v *baseValue) SetDeadline(deadline time.Time) {
+func (v *baseValue) GetDeadline() (deadline, expire int64) {
+	return
+}
+func (v *baseValue) SetDeadline(deadline, expire int64) {
 	panic(`baseValue not support SetDeadline`)
 }
+func (v *baseValue) Timer() *timerNode {
+	panic(`baseValue not support Timer`)
+}
+func (v *baseValue) Touch(now int64, create bool) {
+	v.accessed = now
+	if create {
+		v.created = now
+	}
+}
+func (v *baseValue) Touched() (created, accessed int64) {
+	return v.created, v.accessed
+}
+func (v *baseValue) Pinned() bool {
+	return v.pinned
+}
+func (v *baseValue) SetPinned(pinned bool) {
+	v.pinned = pinned
+}
 
 type deadlineValue struct {
 	baseValue
-	deadline time.Time
+	deadline int64
+	expire   int64
+	timer    timerNode
 }
 
-func (v *deadlineValue) IsDeleted() bool {
-	return !v.deadline.After(time.Now())
+func (v *deadlineValue) IsDeleted(now int64) bool {
+	return v.deadline != 0 && v.deadline <= now
 }
-func (v *deadlineValue) SetDeadline(deadline time.Time) {
+func (v *deadlineValue) GetDeadline() (deadline, expire int64) {
+	return v.deadline, v.expire
+}
+func (v *deadlineValue) SetDeadline(deadline, expire int64) {
 	v.deadline = deadline
+	v.expire = expire
+}
+func (v *deadlineValue) Timer() *timerNode {
+	return &v.timer
 }
@@ -1,90 +1,582 @@
 package gcache
 
 import (
+	"strings"
 	"sync"
 	"time"
 )
 
 type wrapper struct {
-	impl   LowCache
-	ticker *time.Ticker
+	impl LowCache
+	// keyed is impl if it reports the normalised keys, otherwise nil
+	keyed   keyedCache
+	ticker  Ticker
+	tags    *tagIndex
+	keyFunc KeyFunc
+	// prefix indexes string keys for DeletePrefix, nil if disabled
+	prefix     *radixTree
+	namespaces map[string]*namespace
+	// evicting is set while a namespace over quota evicts its own entry
+	evicting bool
+	// refs the referenced entries by normalised key
+	refs      map[interface{}]*reference
+	onRemoval RemovalListener
+	// mrc estimates the miss ratio curve, nil if disabled
+	mrc *shards
 
 	closed chan struct{}
 	m      sync.Mutex
 }
 
+func newWrapper(impl LowCache, keyFunc KeyFunc, prefixIndex bool, onRemoval RemovalListener) *wrapper {
+	w := &wrapper{
+		impl:       impl,
+		tags:       newTagIndex(keyFunc),
+		keyFunc:    keyFunc,
+		namespaces: make(map[string]*namespace),
+		refs:       make(map[interface{}]*reference),
+		onRemoval:  onRemoval,
+		closed:     make(chan struct{}),
+	}
+	if prefixIndex {
+		w.prefix = newRadixTree()
+	}
+	if isKeyed(impl) {
+		w.keyed = impl.(keyedCache)
+		w.keyed.setKeyedListener(w.removed)
+	} else {
+		setListener(impl, func(k, key, value interface{}, reason RemovalReason) {
+			// impl does not report the normalised key
+			k, _ = mapKey(keyFunc, key)
+			w.removed(k, key, value, reason)
+		})
+	}
+	return w
+}
+
+// removed updates the indexes by the normalised key k
+func (w *wrapper) removed(k, key, value interface{}, reason RemovalReason) {
+	if w.evicting {
+		reason = RemovalEvicted
+	}
+	if reason != RemovalReplaced {
+		w.tags.remove(k)
+		if w.prefix != nil {
+			if s, ok := keyString(k); ok {
+				w.prefix.Delete(s)
+			}
+		}
+		if nk, ok := k.(NamespacedKey); ok {
+			if ns := w.namespaces[nk.Namespace]; ns != nil && ns.remove(k) && reason == RemovalEvicted {
+				ns.evictions++
+			}
+		}
+	}
+	// the listener is called by the last Release
+	if !w.detach(k, reason) && w.onRemoval != nil {
+		w.onRemoval(key, value, reason)
+	}
+}
+
+// storedKey the key passed by the caller when the entry was put and its normalised key
+type storedKey struct {
+	k, key interface{}
+}
+
+// rangeKeyed calls f for each live entry with its normalised key
+func (w *wrapper) rangeKeyed(f func(k, key, value interface{}) bool) {
+	if w.keyed != nil {
+		w.keyed.rangeKeyed(f)
+		return
+	}
+	rangeLow(w.impl, func(key, value interface{}) bool {
+		k, _ := mapKey(w.keyFunc, key)
+		return f(k, key, value)
+	})
+}
+
+// deleteStored deletes the entries by their normalised keys, by the keys passed by the caller if impl does not report them
+func (w *wrapper) deleteStored(keys []storedKey) (changed int) {
+	vals := make([]interface{}, len(keys))
+	if w.keyed != nil {
+		for i, sk := range keys {
+			vals[i] = sk.k
+		}
+		return w.keyed.deleteKeyed(vals...)
+	}
+	for i, sk := range keys {
+		vals[i] = sk.key
+	}
+	return w.impl.Delete(vals...)
+}
+
+// keyString returns the normalised key k if it is a string.
+// A NamespacedKey is joined to its namespace by a zero byte, so namespaces are separate branches of the prefix index.
+func keyString(k interface{}) (s string, ok bool) {
+	if nk, namespaced := k.(NamespacedKey); namespaced {
+		s, ok = nk.Key.(string)
+		if ok {
+			s = nk.Namespace + "\x00" + s
+		}
+	} else {
+		s, ok = k.(string)
+	}
+	return
+}
+
+// add the value to impl, the indexes are updated
+func (w *wrapper) add(key, value interface{}) (added bool) {
+	ns := w.reserve(key)
+	added = w.impl.Add(key, value)
+	if added {
+		w.inserted(key, ns)
+	}
+	return
+}
+
+// put the value to impl, the indexes are updated
+func (w *wrapper) put(key, value interface{}) {
+	ns := w.reserve(key)
+	w.impl.Put(key, value)
+	w.inserted(key, ns)
+}
+
+// reserve returns the namespace of key, if the key is new and the namespace is full its own entry is evicted
+func (w *wrapper) reserve(key interface{}) (ns *namespace) {
+	nk, ok := key.(NamespacedKey)
+	if !ok {
+		return
+	}
+	ns = w.namespace(nk.Namespace)
+	if ns.quota > 0 && ns.len() >= ns.quota && !contains(w.impl, key) {
+		w.evictNamespace(ns)
+	}
+	return
+}
+
+// evictNamespace evicts the least recently put or read entry of the namespace, pinned entries are skipped
+func (w *wrapper) evictNamespace(ns *namespace) {
+	var (
+		victim storedKey
+		found  bool
+	)
+	for ele := ns.entries.Front(); ele != nil; ele = ele.Next() {
+		if sk := ele.Value.(storedKey); !w.pinned(sk) {
+			victim = sk
+			found = true
+			break
+		}
+	}
+	w.evicting = true
+	if found {
+		w.deleteStored([]storedKey{victim})
+	} else {
+		// the namespace is full of expired or pinned entries
+		w.impl.ClearExpired()
+	}
+	w.evicting = false
+}
+
+// pinned reports whether the entry of sk is pinned
+func (w *wrapper) pinned(sk storedKey) bool {
+	if w.keyed != nil {
+		return w.keyed.pinnedKeyed(sk.k)
+	}
+	info, _ := getEntry(w.impl, sk.key)
+	return info.Pinned
+}
+
+// inserted saves the key to the indexes after it is put
+func (w *wrapper) inserted(key interface{}, ns *namespace) {
+	if w.prefix == nil && ns == nil {
+		return
+	}
+	if !contains(w.impl, key) {
+		// lru-k may only save the key to the history
+		return
+	}
+	k, ok := mapKey(w.keyFunc, key)
+	if !ok {
+		return
+	}
+	if ns != nil {
+		ns.put(k, key)
+	}
+	if w.prefix != nil {
+		if s, ok := keyString(k); ok {
+			w.prefix.Insert(s, storedKey{k, key})
+		}
+	}
+}
+
+// read records a read of key for the miss ratio curve and the recency of its namespace
+func (w *wrapper) read(key interface{}) {
+	nk, namespaced := key.(NamespacedKey)
+	if w.mrc == nil && !namespaced {
+		return
+	}
+	k, ok := mapKey(w.keyFunc, key)
+	if !ok {
+		return
+	}
+	if w.mrc != nil {
+		w.mrc.Read(k)
+	}
+	if namespaced {
+		if ns := w.namespaces[nk.Namespace]; ns != nil {
+			ns.read(k)
+		}
+	}
+}
+
 // Add the value to the cache, only when the key does not exist
 func (w *wrapper) Add(key, value interface{}) (added bool) {
 	w.m.Lock()
-	added = w.impl.Add(key, value)
-	w.m.Unlock()
+	defer w.m.Unlock()
+	added = w.add(key, value)
 	return
 }
 
 // Put key value to cache
 func (w *wrapper) Put(key, value interface{}) {
 	w.m.Lock()
-	w.impl.Put(key, value)
-	w.m.Unlock()
+	defer w.m.Unlock()
+	w.put(key, value)
 	return
 }
 
+// TryPut put key value to cache, returns an error wrapping ErrUnhashableKey if the key normalised by KeyFunc cannot be used as a map key
+func (w *wrapper) TryPut(key, value interface{}) error {
+	if k, ok := mapKey(w.keyFunc, key); !ok {
+		if nk, ok := k.(NamespacedKey); ok {
+			k = nk.Key
+		}
+		return CheckKey(k)
+	}
+	w.Put(key, value)
+	return nil
+}
+
 // Get return cache value, if not exists then return ErrNotExists
 func (w *wrapper) Get(key interface{}) (value interface{}, exists bool) {
 	w.m.Lock()
+	defer w.m.Unlock()
+	w.read(key)
 	value, exists = w.impl.Get(key)
-	w.m.Unlock()
+	return
+}
+
+// Peek return cache value without updating recency, frequency, history or deadline
+func (w *wrapper) Peek(key interface{}) (value interface{}, exists bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	value, exists = peek(w.impl, key)
+	return
+}
+
+// GetEntry return the value and metadata of key without updating recency, frequency, history or deadline
+func (w *wrapper) GetEntry(key interface{}) (info EntryInfo, exists bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	info, exists = getEntry(w.impl, key)
+	return
+}
+
+// Contains reports whether the key is cached without updating recency, frequency, history or deadline
+func (w *wrapper) Contains(key interface{}) (exists bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	exists = contains(w.impl, key)
 	return
 }
 
 // BatchPut pairs to cache
 func (w *wrapper) BatchPut(pair ...interface{}) {
 	w.m.Lock()
+	defer w.m.Unlock()
 	count := len(pair)
 	for i := 0; i < count; i += 2 {
 		if i+1 < count {
-			w.impl.Put(pair[i], pair[i+1])
+			w.put(pair[i], pair[i+1])
 		} else {
-			w.impl.Put(pair[i], nil)
+			w.put(pair[i], nil)
 			break
 		}
 	}
-	w.m.Unlock()
 	return
 }
 
 // BatchGet return cache values
 func (w *wrapper) BatchGet(key ...interface{}) (vals []Value) {
 	w.m.Lock()
+	defer w.m.Unlock()
 	vals = make([]Value, len(key))
 	for i, k := range key {
+		w.read(k)
 		vals[i].Value, vals[i].Exists = w.impl.Get(k)
 	}
-	w.m.Unlock()
 	return
 }
 
 // Delete key from cache
 func (w *wrapper) Delete(key ...interface{}) (changed int) {
 	w.m.Lock()
+	defer w.m.Unlock()
 	changed = w.impl.Delete(key...)
-	w.m.Unlock()
 	return
 }
 
 // Len returns the number of cached data
 func (w *wrapper) Len() (count int) {
 	w.m.Lock()
+	defer w.m.Unlock()
 	count = w.impl.Len()
-	w.m.Unlock()
 	return
 }
 
 // Clear all cached data
 func (w *wrapper) Clear() {
 	w.m.Lock()
+	defer w.m.Unlock()
 	w.impl.Clear()
-	w.m.Unlock()
+	w.tags.Clear()
+	if w.prefix != nil {
+		w.prefix.Clear()
+	}
+	for _, ns := range w.namespaces {
+		ns.clear()
+	}
+}
+
+// PutWithTags put key value to cache and associate it with tags, the tags replace the previous ones of key.
+//
+// Tags are kept until the key is evicted, expired or deleted, Put without tags does not change them.
+func (w *wrapper) PutWithTags(key, value interface{}, tags ...interface{}) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.put(key, value)
+	if contains(w.impl, key) {
+		w.tags.Set(key, tags)
+	} else {
+		w.tags.Remove(key)
+	}
+}
+
+// InvalidateTag deletes every key associated with tag, returns the number of deleted keys
+func (w *wrapper) InvalidateTag(tag interface{}) (changed int) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	keys := w.tags.Keys(tag)
+	if len(keys) != 0 {
+		changed = w.deleteStored(keys)
+		// LowCache that does not implement RemovalNotifier
+		for _, sk := range keys {
+			w.tags.remove(sk.k)
+		}
+	}
+	return
+}
+
+// DeleteFunc deletes every live entry for which f returns true, returns the number of deleted keys.
+//
+// DeleteFunc holds the cache lock for the whole walk, f must not call any method of the cache.
+func (w *wrapper) DeleteFunc(f func(key, value interface{}) bool) (changed int) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	var keys []storedKey
+	w.rangeKeyed(func(k, key, value interface{}) bool {
+		if f(key, value) {
+			keys = append(keys, storedKey{k, key})
+		}
+		return true
+	})
+	if len(keys) != 0 {
+		changed = w.deleteStored(keys)
+	}
+	return
+}
+
+// DeletePrefix deletes every key starting with prefix, returns the number of deleted keys.
+// Only string keys, or keys normalised to string by KeyFunc, are matched.
+//
+// It walks the whole cache unless the prefix index is enabled.
+func (w *wrapper) DeletePrefix(prefix string) (changed int) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	changed = w.deletePrefix(``, false, prefix)
+	return
+}
+
+// deletePrefix deletes the string keys starting with prefix, of the namespace if namespaced is true
+func (w *wrapper) deletePrefix(namespace string, namespaced bool, prefix string) (changed int) {
+	if namespaced {
+		prefix = namespace + "\x00" + prefix
+	}
+	match := func(key interface{}) bool {
+		nk, ok := key.(NamespacedKey)
+		return ok == namespaced && (!ok || nk.Namespace == namespace)
+	}
+	var keys []storedKey
+	if w.prefix == nil {
+		w.rangeKeyed(func(k, key, value interface{}) bool {
+			if s, ok := keyString(k); ok && strings.HasPrefix(s, prefix) && match(k) {
+				keys = append(keys, storedKey{k, key})
+			}
+			return true
+		})
+	} else {
+		w.prefix.WalkPrefix(prefix, func(key interface{}) {
+			if sk := key.(storedKey); match(sk.k) {
+				keys = append(keys, sk)
+			}
+		})
+	}
+	if len(keys) != 0 {
+		changed = w.deleteStored(keys)
+		if w.prefix != nil {
+			// LowCache that does not implement RemovalNotifier
+			for _, sk := range keys {
+				s, _ := keyString(sk.k)
+				w.prefix.Delete(s)
+			}
+		}
+	}
+	return
+}
+
+// Pin exempts key from eviction, it is still removed by Delete, Clear and expiry.
+// Returns ErrNotCached, or an error wrapping ErrPinLimit if the pinned entries reached the limit of the capacity.
+func (w *wrapper) Pin(key interface{}) (e error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if p, ok := w.impl.(Pinner); ok {
+		e = p.Pin(key)
+	} else {
+		e = ErrNotPinnable
+	}
+	return
+}
+
+// Unpin makes key evictable again, returns false if it is not pinned
+func (w *wrapper) Unpin(key interface{}) (unpinned bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if p, ok := w.impl.(Pinner); ok {
+		unpinned = p.Unpin(key)
+	}
+	return
+}
+
+// PutPinned put key value to cache and pin it.
+// If an error is returned the value is still put but not pinned.
+func (w *wrapper) PutPinned(key, value interface{}) (e error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.put(key, value)
+	if p, ok := w.impl.(Pinner); ok {
+		e = p.Pin(key)
+	} else {
+		e = ErrNotPinnable
+	}
+	return
+}
+
+// Range calls f for each live entry in eviction order, if f returns false stop the iteration.
+//
+// Range holds the cache lock for the whole walk, f must not call any method of the cache.
+func (w *wrapper) Range(f func(key, value interface{}) bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	rangeLow(w.impl, f)
+}
+
+// Keys returns a snapshot of the live keys in eviction order
+func (w *wrapper) Keys() (keys []interface{}) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	keys = make([]interface{}, 0, w.impl.Len())
+	rangeLow(w.impl, func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return
+}
+
+// Values returns a snapshot of the live values in eviction order
+func (w *wrapper) Values() (vals []interface{}) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	vals = make([]interface{}, 0, w.impl.Len())
+	rangeLow(w.impl, func(key, value interface{}) bool {
+		vals = append(vals, value)
+		return true
+	})
+	return
+}
+
+// Compute calls f with the current value of the key under the cache lock.
+// If keep is true newValue is stored, otherwise the key is deleted.
+//
+// f must not call any method of the cache.
+func (w *wrapper) Compute(key interface{}, f func(oldValue interface{}, exists bool) (newValue interface{}, keep bool)) (value interface{}, exists bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	value, exists = w.compute(key, f)
+	return
+}
+func (w *wrapper) compute(key interface{}, f func(oldValue interface{}, exists bool) (newValue interface{}, keep bool)) (value interface{}, exists bool) {
+	old, loaded := peek(w.impl, key)
+	value, keep := f(old, loaded)
+	if keep {
+		w.put(key, value)
+		// lru-k may only save the key to the history
+		exists = contains(w.impl, key)
+	} else if loaded {
+		w.impl.Delete(key)
+	}
+	if !exists {
+		value = nil
+	}
+	return
+}
+
+// GetOrSet returns the existing value for the key if present, otherwise it stores and returns the given value.
+// loaded is true if the value was loaded, false if stored.
+func (w *wrapper) GetOrSet(key, value interface{}) (actual interface{}, loaded bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.read(key)
+	actual, loaded = w.impl.Get(key)
+	if !loaded {
+		actual = value
+		w.put(key, value)
+	}
+	return
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the cache is equal to old.
+// The old value must be of a comparable type.
+func (w *wrapper) CompareAndSwap(key, oldValue, newValue interface{}) (swapped bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	current, exists := peek(w.impl, key)
+	if exists && current == oldValue {
+		swapped = true
+		w.put(key, newValue)
+	}
+	return
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// The old value must be of a comparable type.
+func (w *wrapper) CompareAndDelete(key, oldValue interface{}) (deleted bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	current, exists := peek(w.impl, key)
+	if exists && current == oldValue {
+		deleted = w.impl.Delete(key) != 0
+	}
+	return
 }
 
 func (w *wrapper) clearExpired(ch <-chan time.Time) {
@@ -101,10 +593,10 @@ func (w *wrapper) clearExpired(ch <-chan time.Time) {
 }
 func (w *wrapper) stop() {
 	w.m.Lock()
+	defer w.m.Unlock()
 	close(w.closed)
 	if w.ticker != nil {
 		w.ticker.Stop()
 	}
 	w.impl.Clear()
-	w.m.Unlock()
 }